@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/yourusername/rmrf/internal/config"
+	"github.com/yourusername/rmrf/internal/deleter"
+	"github.com/yourusername/rmrf/internal/reporter"
+)
+
+// runApply implements "rmrf apply [flags] <plan-file>": delete exactly what
+// an earlier --dry-run --plan-file run listed, refusing anything that has
+// changed since.
+func runApply(args []string) int {
+	fs := flag.NewFlagSet("apply", flag.ExitOnError)
+	colorMode := fs.String("color", "auto", "colorize output: auto, always or never")
+	dryRun := fs.Bool("dry-run", false, "check the plan against the filesystem without deleting")
+	verbose := fs.Bool("verbose", false, "show details about the run")
+	quiet := fs.Bool("quiet", false, "suppress informational notes in the summary")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Printf("Usage: %s apply [--dry-run] <plan-file>\n", os.Args[0])
+		return 1
+	}
+
+	colors, err := newPalette(*colorMode)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Printf("%s %v\n", colors.red("Error:"), err)
+		return 1
+	}
+	plan, err := reporter.ReadPlan(f)
+	f.Close()
+	if err != nil {
+		fmt.Printf("%s reading plan %s: %v\n", colors.red("Error:"), fs.Arg(0), err)
+		return 1
+	}
+
+	defaults, err := loadDefaults()
+	var protected []string
+	if err == nil {
+		protected, err = protectedPaths(defaults)
+	}
+	if err != nil {
+		fmt.Printf("%s %v\n", colors.red("Error:"), err)
+		return 1
+	}
+
+	del := deleter.New(
+		config.WithReporter(newProgress(true, 10*time.Second)),
+		config.WithProtectedPaths(protected...),
+		config.WithDryRun(*dryRun),
+		config.WithVerbose(*verbose),
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	stats, err := del.Apply(ctx, plan)
+	interrupted := errors.Is(err, deleter.ErrCancelled)
+	if interrupted {
+		fmt.Printf("\n%s\n", colors.amber("Interrupted, showing partial results."))
+	}
+	printSummary(stats, colors, *dryRun, *verbose, *quiet)
+
+	switch {
+	case interrupted:
+		return exitInterrupted
+	case len(stats.Errors) > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/yourusername/rmrf/internal/config"
+	"github.com/yourusername/rmrf/internal/deleter"
+	"github.com/yourusername/rmrf/internal/reporter"
+)
+
+// writePlan creates dir/sub/file and dir/other, writes the plan of a dry
+// run deleting dir to a file and returns its path and the paths planned.
+func writePlan(t *testing.T, dir string) (string, []string) {
+	t.Helper()
+	paths := []string{dir, filepath.Join(dir, "sub"), filepath.Join(dir, "sub", "file"), filepath.Join(dir, "other")}
+	if err := os.MkdirAll(paths[1], 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths[2:] {
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	planFile := filepath.Join(t.TempDir(), "plan.json")
+	f, err := os.Create(planFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	d := deleter.New(
+		config.WithReporter(reporter.NoopReporter{}),
+		config.WithDryRun(true),
+		config.WithPlan(reporter.NewPlanJSONWriter(f)),
+	)
+	if _, err := d.Delete(dir); err != nil {
+		t.Fatal(err)
+	}
+	return planFile, paths
+}
+
+func TestApplyHonorsProtectedPaths(t *testing.T) {
+	tests := []struct {
+		name      string
+		protected string // relative to the target's parent, "" for none
+		wantCode  int
+		wantKept  []string // relative to the target's parent
+	}{
+		{"unprotected", "", 0, nil},
+		{"whole plan protected", "target/**", 1, []string{"target", "target/sub", "target/sub/file", "target/other"}},
+		{"subtree protected", "target/sub/**", 1, []string{"target", "target/sub", "target/sub/file"}},
+		{"file protected", "target/sub/file", 1, []string{"target", "target/sub", "target/sub/file"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, err := filepath.EvalSymlinks(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			planFile, paths := writePlan(t, filepath.Join(base, "target"))
+
+			// Protect part of the tree only once the plan is written.
+			configHome := t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", configHome)
+			t.Setenv("RMRF_PROTECTED_PATHS", "")
+			if tt.protected != "" {
+				if err := os.MkdirAll(filepath.Join(configHome, "rmrf"), 0755); err != nil {
+					t.Fatal(err)
+				}
+				pattern := filepath.Join(base, tt.protected) + "\n"
+				if err := os.WriteFile(filepath.Join(configHome, "rmrf", "protected"), []byte(pattern), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if code := runApply([]string{"--color=never", planFile}); code != tt.wantCode {
+				t.Errorf("runApply = %d, want %d", code, tt.wantCode)
+			}
+			for _, path := range paths {
+				rel, _ := filepath.Rel(base, path)
+				_, err := os.Lstat(path)
+				switch kept := slices.Contains(tt.wantKept, filepath.ToSlash(rel)); {
+				case kept && err != nil:
+					t.Errorf("%s removed: %v", rel, err)
+				case !kept && !os.IsNotExist(err):
+					t.Errorf("%s not removed: %v", rel, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/yourusername/rmrf/internal/reporter"
+)
+
+// foregroundFlags are the flags a background run is not given: they pick
+// the targets, ask or check something before deleting, which has been
+// done by then, or concern the terminal, which it has none of.
+var foregroundFlags = map[string]bool{
+	"background": true, "detach": true, "state-file": true, "resume": true, "retry-file": true, "i": true, "I": true,
+	"confirm-entries": true, "confirm-bytes": true, "confirm-name-bytes": true, "force": true,
+	"snapshot": true, "preflight": true, "preflight-abort": true, "no-progress": true,
+	"progress-interval": true, "format": true, "output": true, "color": true, "quiet": true,
+	"verbose": true, "events": true, "events-fd": true, "preset": true, "git-ignored": true,
+	"git-untracked": true, "cachedirs-only": true, "no-glob": true,
+}
+
+// deleteInBackground starts rmrf again, detached from the terminal and the
+// session, to delete targets, which have passed every check, with the
+// flags this run was given that still apply. It does not wait for it: the
+// new process keeps a job state file, see "rmrf status".
+func deleteInBackground(targets []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	// The state file is the one --state-file names, if given.
+	state := flag.Lookup("state-file").Value.String()
+	if state == "" {
+		if _, state, err = reporter.NewJobID(); err != nil {
+			return fmt.Errorf("creating job state file: %w", err)
+		}
+	}
+	job, err := reporter.OpenJob(state, targets)
+	if err != nil {
+		return fmt.Errorf("creating job state file: %w", err)
+	}
+
+	args := []string{"--force", "--no-progress", "--no-glob", "--state-file=" + state}
+	flag.Visit(func(f *flag.Flag) {
+		if foregroundFlags[f.Name] {
+			return
+		}
+		if list, ok := f.Value.(*stringList); ok {
+			for _, v := range *list {
+				args = append(args, fmt.Sprintf("--%s=%s", f.Name, v))
+			}
+			return
+		}
+		args = append(args, fmt.Sprintf("--%s=%s", f.Name, f.Value))
+	})
+	args = append(append(args, "--"), targets...)
+
+	cmd := exec.Command(exe, args...)
+	cmd.SysProcAttr = detachedProcess()
+	if err := cmd.Start(); err != nil {
+		os.Remove(state)
+		return fmt.Errorf("starting background deletion: %w", err)
+	}
+	ref := job.ID()
+	if dir, _ := reporter.JobDir(); filepath.Dir(state) != dir {
+		ref = state
+	}
+	fmt.Printf("Started job %s (pid %d); check on it with: rmrf status %s\n", job.ID(), cmd.Process.Pid, ref)
+	return cmd.Process.Release()
+}
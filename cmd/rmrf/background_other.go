@@ -0,0 +1,15 @@
+//go:build !unix && !windows
+
+package main
+
+import "syscall"
+
+// detachedProcess has nothing to add where there are no sessions.
+func detachedProcess() *syscall.SysProcAttr {
+	return nil
+}
+
+// processAlive can't tell here, and assumes the process is.
+func processAlive(pid int) bool {
+	return true
+}
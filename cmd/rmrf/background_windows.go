@@ -0,0 +1,23 @@
+package main
+
+import "syscall"
+
+// detachedProcess starts a process without a console, in a process group
+// of its own, so closing the console doesn't stop it.
+func detachedProcess() *syscall.SysProcAttr {
+	const detachedProcess = 0x00000008
+	return &syscall.SysProcAttr{CreationFlags: detachedProcess | syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// processAlive reports whether a process with the given ID is running.
+func processAlive(pid int) bool {
+	const queryLimitedInformation = 0x1000
+	const stillActive = 259
+	h, err := syscall.OpenProcess(queryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(h)
+	var code uint32
+	return syscall.GetExitCodeProcess(h, &code) == nil && code == stillActive
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const (
+	ansiReset = "\033[0m"
+	ansiRed   = "\033[31m"
+	ansiGreen = "\033[32m"
+	ansiAmber = "\033[33m"
+)
+
+// palette wraps text in ANSI colors when enabled.
+type palette struct {
+	enabled bool
+}
+
+// newPalette resolves a --color mode. "auto" enables color only when stdout
+// is a terminal, and NO_COLOR (https://no-color.org) disables it unless the
+// mode is "always".
+func newPalette(mode string) (palette, error) {
+	switch mode {
+	case "always":
+		return palette{enabled: true}, nil
+	case "never":
+		return palette{}, nil
+	case "auto":
+		if _, ok := os.LookupEnv("NO_COLOR"); ok {
+			return palette{}, nil
+		}
+		return palette{enabled: isTerminal(os.Stdout)}, nil
+	default:
+		return palette{}, fmt.Errorf("invalid --color value %q (want auto, always or never)", mode)
+	}
+}
+
+func (p palette) wrap(code, s string) string {
+	if !p.enabled {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+func (p palette) red(s string) string   { return p.wrap(ansiRed, s) }
+func (p palette) green(s string) string { return p.wrap(ansiGreen, s) }
+func (p palette) amber(s string) string { return p.wrap(ansiAmber, s) }
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
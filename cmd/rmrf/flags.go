@@ -0,0 +1,362 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/rmrf/internal/config"
+)
+
+// stringList is a flag.Value that collects every occurrence of a
+// repeatable flag.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// optionalCount is a flag.Value for a flag that may be given alone,
+// meaning a default count, or with a count, as in --shred=7.
+type optionalCount struct {
+	n    int
+	bare int // what the flag alone means
+}
+
+// defaultShredPasses is what --shred alone means, as for shred(1).
+const defaultShredPasses = 3
+
+// defaultUntilEmptyPasses is what --until-empty alone means.
+const defaultUntilEmptyPasses = 5
+
+func (c *optionalCount) IsBoolFlag() bool { return true }
+
+func (c *optionalCount) String() string {
+	if c.n == 0 {
+		return "false"
+	}
+	return strconv.Itoa(c.n)
+}
+
+func (c *optionalCount) Set(v string) error {
+	switch v {
+	case "true":
+		c.n = c.bare
+		return nil
+	case "false":
+		c.n = 0
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return fmt.Errorf("invalid count %q", v)
+	}
+	c.n = n
+	return nil
+}
+
+// rateLimit is a flag.Value holding the limits of --rate-limit: a plain
+// number is files a second and a size, with a B, K, M, G or T suffix,
+// bytes a second, either optionally followed by "/s". Both may be given,
+// separated by a comma, as in "2000,100M/s".
+type rateLimit struct {
+	files float64
+	bytes int64
+}
+
+func (l *rateLimit) String() string {
+	var parts []string
+	if l.files > 0 {
+		parts = append(parts, strconv.FormatFloat(l.files, 'g', -1, 64))
+	}
+	if l.bytes > 0 {
+		parts = append(parts, strconv.FormatInt(l.bytes, 10)+"B")
+	}
+	return strings.Join(parts, ",")
+}
+
+func (l *rateLimit) Set(v string) error {
+	for part := range strings.SplitSeq(v, ",") {
+		part = strings.TrimSuffix(strings.TrimSpace(part), "/s")
+		if part != "" && strings.ContainsRune("BKMGT", rune(strings.ToUpper(part)[len(part)-1])) {
+			n, err := parseSize(part)
+			if err != nil || n == 0 {
+				return fmt.Errorf("invalid rate %q", part)
+			}
+			l.bytes = n
+			continue
+		}
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n <= 0 || math.IsInf(n, 0) {
+			return fmt.Errorf("invalid rate %q", part)
+		}
+		l.files = n
+	}
+	return nil
+}
+
+// maxLoad is a flag.Value holding the limit of --max-load: a load average,
+// or with a "%" suffix, an IO pressure.
+type maxLoad struct {
+	load     float64
+	pressure float64
+}
+
+func (m *maxLoad) String() string {
+	if m.pressure > 0 {
+		return strconv.FormatFloat(m.pressure, 'g', -1, 64) + "%"
+	}
+	return strconv.FormatFloat(m.load, 'g', -1, 64)
+}
+
+func (m *maxLoad) Set(v string) error {
+	s, percent := strings.CutSuffix(strings.TrimSpace(v), "%")
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n <= 0 || math.IsInf(n, 0) || percent && n > 100 {
+		return fmt.Errorf("invalid load %q", v)
+	}
+	if percent {
+		m.load, m.pressure = 0, n
+	} else {
+		m.load, m.pressure = n, 0
+	}
+	return nil
+}
+
+// byteSize is a flag.Value holding a byte count written as a plain number
+// or with a binary K, M, G or T suffix, e.g. "512M".
+type byteSize int64
+
+func (b *byteSize) String() string { return strconv.FormatInt(int64(*b), 10) }
+
+func (b *byteSize) Set(v string) error {
+	n, err := parseSize(v)
+	if err != nil {
+		return err
+	}
+	*b = byteSize(n)
+	return nil
+}
+
+func parseSize(v string) (int64, error) {
+	s := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(v)), "B")
+	s = strings.TrimSuffix(s, "I")
+	shift := 0
+	if s != "" {
+		if i := strings.IndexByte("KMGT", s[len(s)-1]); i >= 0 {
+			shift = 10 * (i + 1)
+			s = s[:len(s)-1]
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", v)
+	}
+	if n > math.MaxInt64>>shift {
+		return 0, fmt.Errorf("size %q is too large", v)
+	}
+	return n << shift, nil
+}
+
+// age is a flag.Value holding a duration written as time.ParseDuration
+// accepts, or as a whole number of days or weeks, e.g. "30d" or "2w".
+type age time.Duration
+
+func (a *age) String() string { return time.Duration(*a).String() }
+
+func (a *age) Set(v string) error {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if num, ok := strings.CutSuffix(v, suffix); ok {
+			n, err := strconv.ParseInt(num, 10, 64)
+			if err != nil || n < 0 || n > math.MaxInt64/int64(unit) {
+				return fmt.Errorf("invalid age %q", v)
+			}
+			*a = age(time.Duration(n) * unit)
+			return nil
+		}
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return fmt.Errorf("invalid age %q", v)
+	}
+	*a = age(d)
+	return nil
+}
+
+// userIDs resolves user names, or numeric IDs, to user IDs.
+func userIDs(names []string) ([]int, error) {
+	return resolveIDs(names, func(name string) (string, error) {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return "", err
+		}
+		return u.Uid, nil
+	})
+}
+
+// groupIDs resolves group names, or numeric IDs, to group IDs.
+func groupIDs(names []string) ([]int, error) {
+	return resolveIDs(names, func(name string) (string, error) {
+		g, err := user.LookupGroup(name)
+		if err != nil {
+			return "", err
+		}
+		return g.Gid, nil
+	})
+}
+
+func resolveIDs(names []string, lookup func(string) (string, error)) ([]int, error) {
+	ids := make([]int, 0, len(names))
+	for _, name := range names {
+		id, err := strconv.Atoi(name)
+		if err != nil {
+			s, lerr := lookup(name)
+			if lerr != nil {
+				return nil, lerr
+			}
+			if id, err = strconv.Atoi(s); err != nil {
+				return nil, fmt.Errorf("%s has no numeric ID", name)
+			}
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// loadDefaults reads the settings of the config files and RMRF_*
+// variables, the latter taking precedence. They supply the flag
+// defaults, so flags override them.
+func loadDefaults() (config.File, error) {
+	defaults, err := config.LoadConfigFiles()
+	if err != nil {
+		return config.File{}, err
+	}
+	return config.LoadEnv(defaults)
+}
+
+// protectedPaths returns the patterns of the protected path files, then
+// those of defaults. Every command that deletes anything passes them on,
+// so none is protected less than another.
+func protectedPaths(defaults config.File) ([]string, error) {
+	protected, err := config.LoadProtectedPaths()
+	if err != nil {
+		return nil, fmt.Errorf("reading protected paths: %w", err)
+	}
+	return append(protected, defaults.ProtectedPaths...), nil
+}
+
+// orInt, orBool and orString return a config file setting if it is set
+// and def otherwise.
+func orInt(v *int, def int) int {
+	if v != nil {
+		return *v
+	}
+	return def
+}
+
+func orBool(v *bool, def bool) bool {
+	if v != nil {
+		return *v
+	}
+	return def
+}
+
+func orString(v *string, def string) string {
+	if v != nil {
+		return *v
+	}
+	return def
+}
+
+// detachMode is a flag.Value for --detach, which may be given alone,
+// deleting the moved targets in the foreground, or as --detach=background.
+type detachMode string
+
+const (
+	detachOff        detachMode = ""
+	detachForeground detachMode = "foreground"
+	detachBackground detachMode = "background"
+)
+
+func (m *detachMode) String() string   { return string(*m) }
+func (m *detachMode) IsBoolFlag() bool { return true }
+
+func (m *detachMode) Set(v string) error {
+	switch v {
+	case "true", string(detachForeground):
+		*m = detachForeground
+	case "false":
+		*m = detachOff
+	case string(detachBackground):
+		*m = detachBackground
+	default:
+		return fmt.Errorf("invalid detach mode %q (want background)", v)
+	}
+	return nil
+}
+
+// niceness is a flag.Value for --nice, the CPU scheduling niceness to
+// run at, from -20 to 19; set tells it from the default of leaving the
+// niceness alone.
+type niceness struct {
+	n   int
+	set bool
+}
+
+func (p *niceness) String() string {
+	if !p.set {
+		return ""
+	}
+	return strconv.Itoa(p.n)
+}
+
+func (p *niceness) Set(v string) error {
+	n, err := strconv.Atoi(v)
+	if err != nil || n < -20 || n > 19 {
+		return fmt.Errorf("invalid niceness %q (want -20 to 19)", v)
+	}
+	p.n, p.set = n, true
+	return nil
+}
+
+// ioClass is a flag.Value for --ionice, an IO scheduling class as
+// ionice(1) names them, optionally with a level from 0, the highest, to 7:
+// "idle", "best-effort:7" or "realtime:0".
+type ioClass struct {
+	class string // empty to leave the IO priority alone
+	level int
+}
+
+// ioClasses are the classes --ionice accepts, with their number for
+// ioprio_set(2).
+var ioClasses = map[string]int{"realtime": 1, "best-effort": 2, "idle": 3}
+
+func (c *ioClass) String() string {
+	if c.class == "" || c.class == "idle" {
+		return c.class
+	}
+	return c.class + ":" + strconv.Itoa(c.level)
+}
+
+func (c *ioClass) Set(v string) error {
+	class, level, hasLevel := strings.Cut(v, ":")
+	if _, ok := ioClasses[class]; !ok {
+		return fmt.Errorf("invalid IO class %q (want idle, best-effort or realtime)", class)
+	}
+	c.class, c.level = class, 4 // the default level of best-effort
+	if hasLevel {
+		n, err := strconv.Atoi(level)
+		if err != nil || n < 0 || n > 7 || class == "idle" {
+			return fmt.Errorf("invalid IO priority level %q (want 0 to 7, not with idle)", level)
+		}
+		c.level = n
+	}
+	return nil
+}
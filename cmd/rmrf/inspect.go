@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/yourusername/rmrf/internal/deleter"
+	"github.com/yourusername/rmrf/internal/reporter"
+)
+
+// runInspect implements "rmrf inspect [flags] <path>...": walk each path
+// as a deletion would and print what deleting it would involve, without
+// deleting anything.
+func runInspect(args []string) int {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	colorMode := fs.String("color", "auto", "colorize output: auto, always or never")
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fmt.Printf("Usage: %s inspect [--color MODE] <path>...\n", os.Args[0])
+		return 1
+	}
+
+	colors, err := newPalette(*colorMode)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	del := deleter.New()
+	failed := false
+	for i, path := range fs.Args() {
+		if i > 0 {
+			fmt.Println()
+		}
+		report, err := del.Inspect(ctx, path)
+		if err != nil {
+			fmt.Printf("%s %s: %v\n", colors.red("Error:"), path, err)
+			failed = true
+			continue
+		}
+		printInspectReport(report, colors)
+	}
+
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+func printInspectReport(r deleter.InspectReport, colors palette) {
+	fmt.Printf("%s\n", r.Path)
+	fmt.Printf("- Resolved: %s\n", r.Resolved)
+	if r.Refused != nil {
+		fmt.Printf("- Refused: %s\n", colors.red(r.Refused.Error()))
+	} else {
+		fmt.Printf("- Refused: %s\n", colors.green("no"))
+	}
+	fmt.Printf("- Size: %s in %d files, %d directories, %d symlinks\n",
+		reporter.FormatBytes(r.Bytes), r.Files, r.Dirs, r.Symlinks)
+	if !r.Newest.IsZero() {
+		fmt.Printf("- Newest: %s (%s ago), %s\n", r.Newest.Format(time.DateTime),
+			time.Since(r.Newest).Round(time.Second), r.NewestPath)
+	}
+
+	printInspectList("Mount points crossed", len(r.MountPoints), r.MountPoints, colors)
+	printInspectList("Owned by other users", r.OtherOwners, r.OtherOwned, colors)
+	printInspectList("Setuid/setgid files", r.Setuid, r.SetuidPaths, colors)
+	if r.Unreadable > 0 {
+		fmt.Printf("- Unreadable: %s\n", colors.amber(fmt.Sprint(r.Unreadable)))
+	}
+}
+
+// printInspectList prints a count, flagged when non-zero, and the paths
+// listed for it.
+func printInspectList(label string, n int, paths []string, colors palette) {
+	if n == 0 {
+		fmt.Printf("- %s: 0\n", label)
+		return
+	}
+	fmt.Printf("- %s: %s\n", label, colors.amber(fmt.Sprint(n)))
+	for _, path := range paths {
+		fmt.Printf("    %s\n", path)
+	}
+	if n > len(paths) {
+		fmt.Printf("    ... and %d more\n", n-len(paths))
+	}
+}
@@ -1,38 +1,573 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
-	
+	"os/signal"
+	"regexp"
+	"syscall"
+	"time"
+
+	"github.com/yourusername/rmrf/internal/config"
 	"github.com/yourusername/rmrf/internal/deleter"
+	"github.com/yourusername/rmrf/internal/reporter"
 )
 
+// exitInterrupted follows the shell convention of 128+SIGINT.
+const exitInterrupted = 130
+
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Printf("Usage: %s <directory>\n", os.Args[0])
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "restore":
+			os.Exit(runRestore(os.Args[2:]))
+		case "apply":
+			os.Exit(runApply(os.Args[2:]))
+		case "inspect":
+			os.Exit(runInspect(os.Args[2:]))
+		case "status":
+			os.Exit(runStatus(os.Args[2:]))
+		}
+	}
+
+	defaults, err := loadDefaults()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	colorMode := flag.String("color", orString(defaults.Color, "auto"), "colorize output: auto, always or never")
+	format := flag.String("format", orString(defaults.Format, "text"), "summary format: text, logfmt or json")
+	flag.StringVar(format, "output", *format, "alias for --format")
+	quiet := flag.Bool("quiet", false, "suppress informational notes in the summary")
+	threads := flag.Int("threads", orInt(defaults.Threads, 0), "max concurrent operations (0 tunes for the target filesystem)")
+	engine := flag.String("engine", "standard", "how to remove entries: standard, or uring (experimental, Linux)")
+	var rate rateLimit
+	flag.Var(&rate, "rate-limit", "remove at most this many files a second, and bytes a second given with a size suffix, e.g. 2000 or 100M or 2000,100M")
+	var load maxLoad
+	flag.Var(&load, "max-load", "slow down, and pause if need be, while the load average is above this, or with a % suffix, the IO pressure (Linux)")
+	var nice niceness
+	flag.Var(&nice, "nice", "run at this CPU scheduling niceness, from -20 to 19 (lower than the current one needs privileges)")
+	var ionice ioClass
+	flag.Var(&ionice, "ionice", "run in this IO scheduling class: idle, best-effort[:0-7] or realtime[:0-7] (Linux; idle only on Windows)")
+	adaptiveThreads := flag.Bool("adaptive-threads", false, "vary the active workers, up to --threads or 64, to what removes entries fastest without saturating the device")
+	profile := flag.String("fs-profile", string(config.ProfileAuto), "tune threads and retries for a local or network filesystem: auto, local or network")
+	estimate := flag.Bool("estimate", false, "count the tree before deleting for an accurate progress total and ETA")
+	sample := flag.Int("sample", 0, "size up trees for --estimate and -I from this many random probes instead of a full walk")
+	noProgress := flag.Bool("no-progress", false, "do not show progress")
+	progressInterval := flag.Duration("progress-interval", 10*time.Second, "when stderr is not a terminal, print progress this often (0 for never)")
+	verbose := flag.Bool("verbose", orBool(defaults.Verbose, false), "show details about the run")
+	compareFree := flag.Bool("compare-free-space", false, "report the change in filesystem free space")
+	preflight := flag.Bool("preflight", false, "sample the tree for permission problems before deleting")
+	preflightAbort := flag.Bool("preflight-abort", false, "abort instead of warning when --preflight predicts problems")
+	dryRun := flag.Bool("dry-run", orBool(defaults.DryRun, false), "simulate without deleting")
+	trash := flag.Bool("trash", orBool(defaults.Trash, false), "move targets to the trash instead of deleting them")
+	planFile := flag.String("plan-file", "", "write every path that is (or would be) deleted to this file as JSON lines")
+	againstPlan := flag.String("diff-against-plan", "", "keep and report as drift every entry not in this plan file, written earlier by --dry-run --plan-file")
+	oneFS := flag.Bool("one-file-system", orBool(defaults.OneFileSystem, false), "leave directories on other filesystems (mounts inside the tree) alone")
+	skipSymlinks := flag.Bool("skip-symlinks", false, "leave symlinks, and the directories holding them, in place instead of removing them")
+	followSymlinks := flag.Bool("follow-symlinks", false, "also delete the contents of directories symlinks point to, on the same filesystem")
+	special := flag.String("special", "delete", "what to do with FIFOs, sockets and device nodes: delete, skip or error")
+	noChmod := flag.Bool("no-chmod", false, "never change permissions to delete something; report it as an error instead")
+	clearAttrs := flag.Bool("clear-attrs", false, "clear immutable and append-only attributes (chattr -i -a) that prevent removal")
+	errorsFile := flag.String("errors-file", "", "write the path of every entry that could not be deleted to this file, one per line, for --retry-file")
+	retryFile := flag.String("retry-file", "", "delete only the paths listed in this file, as --errors-file writes it, instead of targets")
+	retries := flag.Int("retries", config.DefaultOptions.RetryRounds, "retry entries that failed with a transient error (busy, locked) this many times at the end of the run")
+	retryBackoff := flag.Duration("retry-backoff", config.DefaultOptions.RetryBackoff, "wait this long before the first retry round, doubling for each next one (0 tunes for the target filesystem)")
+	deferLocked := flag.Bool("defer-locked", false, "on Windows, schedule files locked by other processes for deletion at the next reboot")
+	erasureFile := flag.String("erasure-report", "", "write a proof-of-erasure report of every deleted path to this JSON file")
+	erasureHash := flag.Bool("erasure-hash", false, "with --erasure-report, record the SHA-256 of each file before deleting it")
+	manifestFile := flag.String("manifest", "", "write the path, type, size, mtime and status of every processed entry to this CSV file")
+	deleteSubvols := flag.Bool("delete-subvolumes", false, "delete nested btrfs subvolumes instead of skipping them (needs root or user_subvol_rm_allowed)")
+	snapshot := flag.Bool("snapshot", false, "take a read-only btrfs or ZFS snapshot of each target's subvolume or dataset first")
+	archive := flag.String("archive", "", "write everything deleted to this new .tar, .tar.gz or .tar.zst first")
+	auditLog := flag.String("audit-log", "", "append a line for every removed entry to this file")
+	inBackground := flag.Bool("background", false, "delete in a detached process that survives logging out, keeping a job state file; see rmrf status")
+	stateFile := flag.String("state-file", "", "keep progress and the final stats of the run in this JSON file")
+	checkpointFile := flag.String("checkpoint", "", "save the traversal state to this file every few seconds, so an interrupted run can be resumed")
+	resume := flag.String("resume", "", "resume the interrupted run that saved this checkpoint file")
+	var detach detachMode
+	flag.Var(&detach, "detach", "rename each target aside first, freeing its path at once, then delete it; --detach=background deletes it in a process of its own")
+	untilEmpty := optionalCount{bare: defaultUntilEmptyPasses}
+	flag.Var(&untilEmpty, "until-empty", "walk the targets again while new entries keep appearing in them, up to 5 passes or --until-empty=N")
+	watchWrites := flag.Bool("watch-writes", false, "watch the targets during deletion and warn if another process creates entries in them (Linux)")
+	abortOnWrites := flag.Bool("abort-on-writes", false, "like --watch-writes, but stop the deletion as soon as another process creates an entry in the targets")
+	shred := optionalCount{bare: defaultShredPasses}
+	flag.Var(&shred, "shred", "overwrite files before removing them, 3 times or --shred=N times (not on copy-on-write filesystems or SSDs)")
+	allowHome := flag.Bool("allow-home", false, "allow a target that is the home directory, directly in it, or above it")
+	allowMount := flag.Bool("allow-mountpoint", false, "allow a target that is itself a mount point")
+	noGlob := flag.Bool("no-glob", false, "treat arguments literally instead of expanding glob patterns")
+	interactive := flag.Bool("i", false, "prompt before removing each file and descending into each directory")
+	confirmOnce := flag.Bool("I", false, "prompt once before a large deletion, showing what it covers")
+	confirmEntries := flag.Int("confirm-entries", 1000, "with -I, prompt when more than this many entries would be removed")
+	confirmBytes := byteSize(1 << 30)
+	flag.Var(&confirmBytes, "confirm-bytes", "with -I, prompt when more than this many bytes would be removed (K/M/G/T suffixes)")
+	force := flag.Bool("force", false, "don't ask for the name of risky targets to be typed or, as root, to confirm deleting setuid files and other users' files; allow targets holding the working directory or rmrf itself")
+	var confirmNameBytes byteSize
+	flag.Var(&confirmNameBytes, "confirm-name-bytes", "also ask for the name of targets holding more than this many bytes (K/M/G/T suffixes)")
+	events := flag.String("events", "", "stream events while running: ndjson")
+	eventsFD := flag.Int("events-fd", 1, "file descriptor to write --events to")
+	resultHash := flag.Bool("result-hash", false, "report the SHA-256 of the sorted deleted paths and sizes")
+	var olderThan, newerThan age
+	flag.Var(&olderThan, "older-than", "only delete files older than this (e.g. 30d, 2w, 12h)")
+	flag.Var(&newerThan, "newer-than", "only delete files newer than this")
+	ageTime := flag.String("time", "mtime", "timestamp --older-than and --newer-than go by: mtime, atime or ctime")
+	var largerThan, smallerThan byteSize
+	flag.Var(&largerThan, "larger-than", "only delete files larger than this many bytes (K/M/G/T suffixes)")
+	flag.Var(&smallerThan, "smaller-than", "only delete files smaller than this many bytes (K/M/G/T suffixes)")
+	var owners, groups stringList
+	flag.Var(&owners, "owned-by", "only delete files owned by this user, a name or UID (repeatable)")
+	flag.Var(&groups, "group", "only delete files belonging to this group, a name or GID (repeatable)")
+	keepRoot := flag.Bool("keep-root", false, "delete everything inside each directory target, dotfiles included, but keep the directory")
+	pruneEmpty := flag.Bool("prune-empty", false, "remove only directories that are empty or hold nothing but empty directories")
+	gitIgnored := flag.Bool("git-ignored", false, "delete only the files git ignores in each target, like git clean -fdX")
+	gitUntracked := flag.Bool("git-untracked", false, "delete only the files git does not track in each target, ignored ones too, like git clean -fdx")
+	var presets stringList
+	flag.Var(&presets, "preset", "delete the build and cache directories of a kind of project found under each target: node, rust or python (repeatable)")
+	cacheDirsOnly := flag.Bool("cachedirs-only", false, "delete only the directories in each target marked with a CACHEDIR.TAG file")
+	skipCacheDirs := flag.Bool("skip-cachedir-tag", false, "keep directories marked with a CACHEDIR.TAG file")
+	nestedIgnores := flag.Bool("nested-rmrfignore", false, "honor .rmrfignore files in every directory, not just the targets")
+	minDepth := flag.Int("min-depth", 0, "keep everything less than this many levels below the target (its entries are at 1)")
+	maxDepth := flag.Int("max-depth", 0, "keep everything more than this many levels below the target (0 for no limit)")
+	var includes stringList
+	flag.Var(&includes, "include", "only delete files matching this glob (repeatable)")
+	flag.Var(&includes, "match", "alias for --include")
+	var regexps stringList
+	flag.Var(&regexps, "regex", "only delete files matching this regular expression (repeatable)")
+	excludes := stringList(defaults.Excludes)
+	var excludeFiles stringList
+	flag.Var(&excludes, "exclude", "keep files and directories matching this glob (repeatable)")
+	flag.Var(&excludeFiles, "exclude-from", "read exclude globs from a file, one per line (repeatable)")
+	flag.Parse()
+
+	// A resumed run is the run it resumes, from where it was run.
+	args := os.Args[1:]
+	var resumed *reporter.Checkpoint
+	if *resume != "" {
+		if flag.NArg() > 0 || *inBackground || detach != detachOff {
+			fmt.Println("Error: --resume takes its targets from the checkpoint and runs in the foreground")
+			os.Exit(1)
+		}
+		if resumed, err = reporter.ReadCheckpoint(*resume); err == nil {
+			err = os.Chdir(resumed.Dir)
+		}
+		if err == nil {
+			err = flag.CommandLine.Parse(resumed.Args)
+		}
+		if err != nil {
+			fmt.Printf("Error: resuming: %v\n", err)
+			os.Exit(1)
+		}
+		args, *checkpointFile = resumed.Args, *resume
+	}
+	if *checkpointFile != "" && detach != detachOff {
+		fmt.Println("Error: --checkpoint cannot be combined with --detach")
 		os.Exit(1)
 	}
 
-	del := deleter.New(
-		deleter.WithMaxThreads(8),
-		deleter.WithDryRun(false),
-	)
+	// A retry takes its targets, literally, from the list of a failed run.
+	targets := flag.Args()
+	if *retryFile != "" {
+		if len(targets) > 0 {
+			fmt.Println("Error: --retry-file takes its targets from the file")
+			os.Exit(1)
+		}
+		if targets, err = readFailedPaths(*retryFile); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(targets) == 0 {
+			fmt.Println("Nothing to retry: every listed path is gone.")
+			os.Exit(0)
+		}
+		*noGlob = true
+	}
 
-	stats, err := del.Delete(os.Args[1])
+	if len(targets) < 1 {
+		fmt.Printf("Usage: %s [flags] <path>...\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	colors, err := newPalette(*colorMode)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
+	for _, path := range excludeFiles {
+		patterns, err := config.ReadPatternFile(path)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		excludes = append(excludes, patterns...)
+	}
+
+	var includeRegexps []*regexp.Regexp
+	for _, expr := range regexps {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			fmt.Printf("Error: invalid --regex: %v\n", err)
+			os.Exit(1)
+		}
+		includeRegexps = append(includeRegexps, re)
+	}
+
+	ownerUIDs, err := userIDs(owners)
+	var groupGIDs []int
+	if err == nil {
+		groupGIDs, err = groupIDs(groups)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	protected, err := protectedPaths(defaults)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch *format {
+	case "text", "logfmt", "json":
+	default:
+		fmt.Printf("Error: invalid --format value %q (want text, logfmt or json)\n", *format)
+		os.Exit(1)
+	}
+	switch config.Engine(*engine) {
+	case config.EngineStandard, config.EngineURing:
+	default:
+		fmt.Printf("Error: invalid --engine value %q (want standard or uring)\n", *engine)
+		os.Exit(1)
+	}
+	switch config.Profile(*profile) {
+	case config.ProfileAuto, config.ProfileLocal, config.ProfileNetwork:
+	default:
+		fmt.Printf("Error: invalid --fs-profile value %q (want auto, local or network)\n", *profile)
+		os.Exit(1)
+	}
+	if *minDepth < 0 || *maxDepth < 0 {
+		fmt.Println("Error: --min-depth and --max-depth cannot be negative")
+		os.Exit(1)
+	}
+	switch config.TimeField(*ageTime) {
+	case config.TimeModified, config.TimeAccessed, config.TimeChanged:
+	default:
+		fmt.Printf("Error: invalid --time value %q (want mtime, atime or ctime)\n", *ageTime)
+		os.Exit(1)
+	}
+	specialPolicies := map[string]config.SpecialPolicy{
+		"delete": config.SpecialDelete,
+		"skip":   config.SpecialSkip,
+		"error":  config.SpecialError,
+	}
+	specialPolicy, ok := specialPolicies[*special]
+	if !ok {
+		fmt.Printf("Error: invalid --special value %q (want delete, skip or error)\n", *special)
+		os.Exit(1)
+	}
+	// JSON output must be the only thing on stdout.
+	jsonOut := *format == "json"
+	// A background deletion writes the reports itself.
+	background := (*inBackground || detach == detachBackground) && !*dryRun
+	if background && *interactive {
+		fmt.Println("Error: -i cannot be combined with --background")
+		os.Exit(1)
+	}
+
+	// A dry run lists what it would delete on stdout unless a plan file
+	// takes the list; progress redraws would garble that listing.
+	var plan func(reporter.PlanEntry)
+	switch {
+	case *planFile != "" && background:
+	case *planFile != "":
+		f, err := os.Create(*planFile)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		plan = reporter.NewPlanJSONWriter(f)
+	case *dryRun && !jsonOut:
+		plan = printPlanEntry
+	}
+
+	var preview []reporter.PlanEntry
+	if *againstPlan != "" {
+		f, err := os.Open(*againstPlan)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		preview, err = reporter.ReadPlan(f)
+		f.Close()
+		if err != nil {
+			fmt.Printf("Error: reading plan %s: %v\n", *againstPlan, err)
+			os.Exit(1)
+		}
+	}
+
+	var erasure *reporter.ErasureReport
+	if *erasureFile != "" && !background {
+		if *dryRun || *trash {
+			fmt.Println("Error: --erasure-report records what is actually deleted and cannot be combined with --dry-run or --trash")
+			os.Exit(1)
+		}
+		erasure = reporter.NewErasureReport()
+	}
+
+	var manifest *reporter.ManifestCSV
+	if *manifestFile != "" && !background {
+		f, err := os.Create(*manifestFile)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		manifest = reporter.NewManifestCSV(f)
+	}
+
+	progress := newProgress(!*noProgress && !(*dryRun && *planFile == ""), *progressInterval)
+	var job *reporter.Job
+	if *stateFile != "" && !background {
+		if job, err = reporter.OpenJob(*stateFile, targets); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		progress = job
+	}
+
+	var emit func(reporter.Event)
+	switch *events {
+	case "":
+	case "ndjson":
+		w := os.Stdout
+		if *eventsFD != 1 {
+			w = os.NewFile(uintptr(*eventsFD), "events")
+		}
+		emit = reporter.NewEventJSONWriter(w)
+		progress = reporter.NewEventReporter(emit, time.Second)
+	default:
+		fmt.Printf("Error: invalid --events value %q (want ndjson)\n", *events)
+		os.Exit(1)
+	}
+
+	for _, name := range presets {
+		if _, ok := config.Presets[name]; !ok {
+			fmt.Printf("Error: unknown --preset %q (want node, rust or python)\n", name)
+			os.Exit(1)
+		}
+	}
+
+	var gitClean config.GitClean
+	switch {
+	case *gitIgnored && *gitUntracked:
+		fmt.Println("Error: --git-ignored and --git-untracked cannot be combined")
+		os.Exit(1)
+	case *gitIgnored:
+		gitClean = config.GitIgnored
+	case *gitUntracked:
+		gitClean = config.GitUntracked
+	}
+
+	symlinks := config.SymlinkUnlink
+	switch {
+	case *skipSymlinks && *followSymlinks:
+		fmt.Println("Error: --skip-symlinks and --follow-symlinks cannot be combined")
+		os.Exit(1)
+	case *skipSymlinks:
+		symlinks = config.SymlinkSkip
+	case *followSymlinks:
+		symlinks = config.SymlinkFollow
+	}
+
+	opts := []config.Option{
+		config.WithReporter(progress),
+		config.WithMaxThreads(*threads),
+		config.WithAdaptiveThreads(*adaptiveThreads),
+		config.WithRateLimit(rate.files, rate.bytes),
+		config.WithAdaptiveLoad(load.load),
+		config.WithMaxPressure(load.pressure),
+		config.WithEngine(config.Engine(*engine)),
+		config.WithProfile(config.Profile(*profile)),
+		config.WithDryRun(*dryRun),
+		config.WithPlan(plan),
+		config.WithResultDiffAgainstPreview(preview),
+		config.WithEvents(emit),
+		config.WithNoGlob(*noGlob),
+		config.WithTrash(*trash),
+		config.WithOneFileSystem(*oneFS),
+		config.WithAllowMountpoint(*allowMount),
+		config.WithNoChmod(*noChmod),
+		config.WithSymlinkPolicy(symlinks),
+		config.WithSpecialPolicy(specialPolicy),
+		config.WithClearAttrs(*clearAttrs),
+		config.WithDeferLocked(*deferLocked),
+		config.WithRetries(*retries, *retryBackoff),
+		config.WithProtectedPaths(protected...),
+		config.WithVerbose(*verbose),
+		config.WithEstimate(*estimate),
+		config.WithSampledEstimate(*sample),
+		config.WithInteractive(*interactive),
+		config.WithDeleteSummaryCompare(*compareFree),
+		config.WithIncludeOnly(includes...),
+		config.WithIncludeRegexps(includeRegexps...),
+		config.WithOlderThan(time.Duration(olderThan)),
+		config.WithNewerThan(time.Duration(newerThan)),
+		config.WithAgeTime(config.TimeField(*ageTime)),
+		config.WithLargerThan(int64(largerThan)),
+		config.WithSmallerThan(int64(smallerThan)),
+		config.WithOwners(ownerUIDs...),
+		config.WithGroups(groupGIDs...),
+		config.WithKeepRoot(*keepRoot),
+		config.WithPruneEmpty(*pruneEmpty),
+		config.WithGitClean(gitClean),
+		config.WithPresets(presets...),
+		config.WithCacheDirsOnly(*cacheDirsOnly),
+		config.WithSkipCacheDirs(*skipCacheDirs),
+		config.WithNestedIgnoreFiles(*nestedIgnores),
+		config.WithMinDepth(*minDepth),
+		config.WithMaxDepth(*maxDepth),
+		config.WithExcludes(excludes),
+		config.WithResultHash(*resultHash),
+		config.WithForce(*force),
+		config.WithShred(shred.n),
+		config.WithUntilEmpty(untilEmpty.n),
+		config.WithWatchWrites(*watchWrites, *abortOnWrites),
+		config.WithAuditLog(*auditLog),
+		config.WithArchive(*archive),
+		config.WithSnapshot(*snapshot),
+		config.WithDeleteSubvolumes(*deleteSubvols),
+		config.WithAllowHome(*allowHome),
+		config.WithDetach(detach != detachOff),
+		config.WithCheckpoint(*checkpointFile, args),
+	}
+	if resumed != nil {
+		opts = append(opts, config.WithResume(resumed.Completed))
+	}
+	if background {
+		opts = append(opts, config.WithHandoff(deleteInBackground))
+	}
+	if manifest != nil {
+		opts = append(opts, config.WithManifest(manifest.Add))
+	}
+	var failed failedPaths
+	if *errorsFile != "" {
+		opts = append(opts, config.WithFailedPaths(failed.add))
+	}
+	if erasure != nil {
+		opts = append(opts, config.WithErasureRecord(erasure.Add, *erasureHash))
+	}
+	if *confirmOnce {
+		opts = append(opts, config.WithConfirmLarge(*confirmEntries, int64(confirmBytes)))
+	}
+	if !*force {
+		opts = append(opts, config.WithConfirmByName(int64(confirmNameBytes)), config.WithConfirmSensitive(true))
+	}
+	// Before any work, so walks and confirmations run at it too.
+	if err := setPriority(nice, ionice); err != nil {
+		fmt.Printf("%s %v\n", colors.red("Error:"), err)
+		os.Exit(1)
+	}
+	del := deleter.New(opts...)
 
-	fmt.Printf("\nDeletion complete:\n")
-	fmt.Printf("- Files: %d\n", stats.FilesDeleted)
-	fmt.Printf("- Directories: %d\n", stats.DirsDeleted)
-	
-	if len(stats.Errors) > 0 {
-		fmt.Printf("\nEncountered %d errors:\n", len(stats.Errors))
-		for _, err := range stats.Errors {
-			fmt.Printf("  - %v\n", err)
+	if *dryRun && !jsonOut {
+		// Expansion errors are reported again by the run itself.
+		paths, _ := del.Expand(targets...)
+		for _, path := range paths {
+			fmt.Printf("would delete: %s\n", path)
 		}
+	}
+
+	if *preflight || *preflightAbort {
+		report, err := del.Preflight(targets...)
+		if err != nil {
+			fmt.Printf("%s %v\n", colors.red("Error:"), err)
+			os.Exit(1)
+		}
+		if report.Denied > 0 {
+			if *preflightAbort {
+				fmt.Printf("%s %s\n", colors.red("Error:"), report)
+				os.Exit(1)
+			}
+			fmt.Printf("%s %s\n", colors.amber("warning:"), report)
+		}
+	}
+
+	// The first SIGINT/SIGTERM cancels the run and lets in-flight removals
+	// finish; restoring default handling makes a second one kill us.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	stats, err := del.DeleteManyContext(ctx, targets...)
+	if stats == nil {
+		if job != nil {
+			job.Finish(nil, err)
+		}
+		fmt.Printf("%s %v\n", colors.red("Error:"), err)
+		os.Exit(1)
+	}
+
+	if manifest != nil {
+		if werr := manifest.Flush(); werr != nil {
+			stats.AddError(fmt.Errorf("writing manifest: %w", werr))
+		}
+	}
+	if erasure != nil {
+		if werr := erasure.WriteFile(*erasureFile, len(stats.Errors)); werr != nil {
+			stats.AddError(fmt.Errorf("writing erasure report: %w", werr))
+		}
+	}
+
+	if *errorsFile != "" && !background {
+		if werr := failed.writeFile(*errorsFile); werr != nil {
+			stats.AddError(fmt.Errorf("writing errors file: %w", werr))
+		}
+	}
+
+	if job != nil {
+		if jerr := job.Finish(stats, err); jerr != nil {
+			fmt.Fprintf(os.Stderr, "Error: writing state file: %v\n", jerr)
+		}
+	}
+
+	if background && err == nil && len(stats.Errors) == 0 {
+		return
+	}
+
+	interrupted := errors.Is(err, deleter.ErrCancelled)
+	if interrupted && !jsonOut {
+		fmt.Printf("\n%s\n", colors.amber("Interrupted, showing partial results."))
+	}
+
+	switch *format {
+	case "logfmt":
+		fmt.Println(stats.Logfmt())
+	case "json":
+		fmt.Println(stats.JSON())
+	default:
+		printSummary(stats, colors, *dryRun, *verbose, *quiet)
+	}
+
+	if err != nil && !interrupted {
+		if jsonOut {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		} else {
+			fmt.Printf("%s %v\n", colors.red("Error:"), err)
+		}
+	}
+
+	switch {
+	case interrupted:
+		os.Exit(exitInterrupted)
+	case err != nil || len(stats.Errors) > 0:
 		os.Exit(1)
 	}
 }
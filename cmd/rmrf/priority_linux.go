@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// ioprio_set(2) constants.
+const (
+	ioprioWhoProcess = 1
+	ioprioClassShift = 13
+)
+
+// setPriority sets the niceness and IO class of every thread of the
+// process. Both are per thread on Linux, and threads started later take
+// them from the thread starting them, so the process is scanned again
+// until no thread turns up that hasn't been set.
+func setPriority(nice niceness, io ioClass) error {
+	if !nice.set && io.class == "" {
+		return nil
+	}
+	done := make(map[int]bool)
+	for {
+		tasks, err := os.ReadDir("/proc/self/task")
+		if err != nil {
+			return err
+		}
+		fresh := false
+		for _, task := range tasks {
+			tid, err := strconv.Atoi(task.Name())
+			if err != nil || done[tid] {
+				continue
+			}
+			if err := setThreadPriority(tid, nice, io); err != nil {
+				return err
+			}
+			done[tid], fresh = true, true
+		}
+		if !fresh {
+			return nil
+		}
+	}
+}
+
+func setThreadPriority(tid int, nice niceness, io ioClass) error {
+	if nice.set {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, tid, nice.n); err != nil {
+			return fmt.Errorf("setting niceness %d: %w", nice.n, err)
+		}
+	}
+	if io.class != "" {
+		prio := ioClasses[io.class]<<ioprioClassShift | io.level
+		if _, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid), uintptr(prio)); errno != 0 {
+			return fmt.Errorf("setting IO class %s: %w", io.String(), errno)
+		}
+	}
+	return nil
+}
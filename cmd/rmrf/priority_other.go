@@ -0,0 +1,13 @@
+//go:build !unix && !windows
+
+package main
+
+import "errors"
+
+// setPriority has no scheduling priorities to set here.
+func setPriority(nice niceness, io ioClass) error {
+	if nice.set || io.class != "" {
+		return errors.New("--nice and --ionice are not supported on this platform")
+	}
+	return nil
+}
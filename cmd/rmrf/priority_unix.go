@@ -0,0 +1,23 @@
+//go:build unix && !linux
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+// setPriority sets the niceness of the process. IO classes are Linux's.
+func setPriority(nice niceness, io ioClass) error {
+	if io.class != "" {
+		return errors.New("--ionice is only supported on Linux and Windows")
+	}
+	if !nice.set {
+		return nil
+	}
+	if err := syscall.Setpriority(syscall.PRIO_PROCESS, 0, nice.n); err != nil {
+		return fmt.Errorf("setting niceness %d: %w", nice.n, err)
+	}
+	return nil
+}
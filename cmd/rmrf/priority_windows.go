@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+var procSetPriorityClass = syscall.NewLazyDLL("kernel32.dll").NewProc("SetPriorityClass")
+
+// SetPriorityClass classes, and the mode lowering IO and memory priority
+// too.
+const (
+	highPriorityClass          = 0x00000080
+	aboveNormalPriorityClass   = 0x00008000
+	normalPriorityClass        = 0x00000020
+	belowNormalPriorityClass   = 0x00004000
+	idlePriorityClass          = 0x00000040
+	processModeBackgroundBegin = 0x00100000
+)
+
+// setPriority maps the niceness to the nearest priority class, and the
+// idle IO class to background mode, which lowers the IO priority of the
+// process. Best-effort is what it has anyway; realtime IO has no
+// equivalent.
+func setPriority(nice niceness, io ioClass) error {
+	if io.class == "realtime" {
+		return errors.New("--ionice realtime is not supported on Windows")
+	}
+	if nice.set {
+		class := uint32(normalPriorityClass)
+		switch {
+		case nice.n <= -15:
+			class = highPriorityClass
+		case nice.n < 0:
+			class = aboveNormalPriorityClass
+		case nice.n >= 10:
+			class = idlePriorityClass
+		case nice.n > 0:
+			class = belowNormalPriorityClass
+		}
+		if err := setPriorityClass(class); err != nil {
+			return fmt.Errorf("setting niceness %d: %w", nice.n, err)
+		}
+	}
+	if io.class == "idle" {
+		if err := setPriorityClass(processModeBackgroundBegin); err != nil {
+			return fmt.Errorf("setting IO class idle: %w", err)
+		}
+	}
+	return nil
+}
+
+func setPriorityClass(class uint32) error {
+	process, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return err
+	}
+	if r, _, err := procSetPriorityClass.Call(uintptr(process), uintptr(class)); r == 0 {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/yourusername/rmrf/internal/reporter"
+)
+
+// newProgress picks how progress is shown on stderr: a redrawn line on a
+// terminal, a plain line every interval when stderr is a pipe or a log,
+// and nothing if disabled or the interval is zero.
+func newProgress(enabled bool, interval time.Duration) reporter.Reporter {
+	switch {
+	case !enabled:
+		return reporter.NoopReporter{}
+	case isTerminal(os.Stderr):
+		return reporter.NewTerminalReporter(os.Stderr)
+	case interval > 0:
+		return reporter.NewLineReporter(os.Stderr, interval)
+	}
+	return reporter.NoopReporter{}
+}
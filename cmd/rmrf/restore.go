@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/yourusername/rmrf/internal/trash"
+)
+
+// runRestore implements "rmrf restore [flags] <operation-id|log-file|original-path>...".
+// An operation ID or log file restores everything that run trashed; a
+// path restores the most recently trashed item that lived there.
+func runRestore(args []string) int {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	overwrite := fs.Bool("overwrite", false, "replace whatever now occupies the original path")
+	rename := fs.Bool("rename", false, "restore next to an occupied original path as <name>.restored")
+	fs.Parse(args)
+
+	if fs.NArg() < 1 || (*overwrite && *rename) {
+		fmt.Printf("Usage: %s restore [--overwrite | --rename] <operation-id|log-file|original-path>...\n", os.Args[0])
+		return 1
+	}
+
+	policy := trash.ConflictFail
+	switch {
+	case *overwrite:
+		policy = trash.ConflictOverwrite
+	case *rename:
+		policy = trash.ConflictRename
+	}
+
+	failed := false
+	for _, arg := range fs.Args() {
+		items, err := restoreItems(arg)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			failed = true
+			continue
+		}
+		for _, item := range items {
+			dest, err := trash.Restore(item, policy)
+			if err != nil {
+				fmt.Printf("Error: restoring %s: %v\n", item.Original, err)
+				failed = true
+				continue
+			}
+			fmt.Printf("restored %s\n", dest)
+		}
+	}
+
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// restoreItems resolves a restore argument to the items it names.
+func restoreItems(arg string) ([]trash.Item, error) {
+	if path, ok := trash.OpenLogPath(arg); ok {
+		return trash.ReadLog(path)
+	}
+	item, err := trash.Find(arg)
+	if err != nil {
+		return nil, err
+	}
+	return []trash.Item{item}, nil
+}
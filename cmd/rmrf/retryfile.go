@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// failedPaths collects the paths of entries that could not be deleted,
+// for --errors-file.
+type failedPaths struct {
+	mu    sync.Mutex
+	paths map[string]bool
+}
+
+func (f *failedPaths) add(path string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.paths == nil {
+		f.paths = make(map[string]bool)
+	}
+	f.paths[path] = true
+}
+
+// writeFile writes the paths to name, sorted, one per line, replacing
+// whatever list a previous run left there.
+func (f *failedPaths) writeFile(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var b strings.Builder
+	for _, path := range slices.Sorted(maps.Keys(f.paths)) {
+		b.WriteString(path)
+		b.WriteByte('\n')
+	}
+	return os.WriteFile(name, []byte(b.String()), 0600)
+}
+
+// readFailedPaths reads a list written by --errors-file, leaving out the
+// paths that are gone since and those inside another listed directory,
+// which retrying it covers: a directory is listed when an entry in it
+// could not be deleted.
+func readFailedPaths(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var paths []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		path := strings.TrimSuffix(scanner.Text(), "\r")
+		if path == "" {
+			continue
+		}
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sep := string(filepath.Separator)
+	slices.Sort(paths)
+	outer := paths[:0]
+	for _, path := range paths {
+		if len(outer) > 0 && strings.HasPrefix(path, strings.TrimSuffix(outer[len(outer)-1], sep)+sep) {
+			continue
+		}
+		outer = append(outer, path)
+	}
+	return outer, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yourusername/rmrf/internal/reporter"
+)
+
+// runStatus implements "rmrf status <job-id>": print the state of a run
+// started with --background. It exits 0 once the job is done, 1 if it
+// failed or died, and 3 while it is still running.
+func runStatus(args []string) int {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	jsonOut := fs.Bool("json", false, "print the state file as it is")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Printf("Usage: %s status [--json] <job-id>\n", os.Args[0])
+		return 1
+	}
+	path, err := reporter.JobPath(fs.Arg(0))
+	if err == nil {
+		_, err = os.Stat(path)
+	}
+	if err != nil {
+		fmt.Printf("Error: no job %s: %v\n", fs.Arg(0), err)
+		return 1
+	}
+	state, err := reporter.ReadJob(path)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+
+	// A run killed outright leaves its state at running.
+	if state.State == reporter.JobRunning && !processAlive(state.PID) {
+		state.State, state.Error = reporter.JobFailed, fmt.Sprintf("process %d is gone without finishing", state.PID)
+	}
+
+	if *jsonOut {
+		data, _ := os.ReadFile(path)
+		os.Stdout.Write(data)
+	} else {
+		printJobState(state)
+	}
+
+	switch state.State {
+	case reporter.JobDone:
+		return 0
+	case reporter.JobRunning:
+		return 3
+	}
+	return 1
+}
+
+func printJobState(s reporter.JobState) {
+	fmt.Printf("Job %s: %s\n", s.ID, s.State)
+	fmt.Printf("- Targets: %s\n", strings.Join(s.Targets, ", "))
+	fmt.Printf("- Started: %s (pid %d)\n", s.Started.Format(time.RFC3339), s.PID)
+	if s.State == reporter.JobRunning {
+		progress := fmt.Sprintf("%d/%d", s.Processed, s.Total)
+		if s.Total > 0 {
+			progress += fmt.Sprintf(" (%.0f%%)", float64(s.Processed)/float64(s.Total)*100)
+		}
+		fmt.Printf("- Progress: %s, %.2f/s, ETA %.0fs, as of %s\n", progress, s.Rate, s.ETA, s.Updated.Format(time.RFC3339))
+	} else {
+		fmt.Printf("- Finished: %s\n", s.Updated.Format(time.RFC3339))
+	}
+	var stats struct {
+		FilesDeleted int64    `json:"filesDeleted"`
+		DirsDeleted  int64    `json:"dirsDeleted"`
+		BytesFreed   int64    `json:"bytesFreed"`
+		Errors       []string `json:"errors"`
+	}
+	if s.Stats != nil && json.Unmarshal(s.Stats, &stats) == nil {
+		fmt.Printf("- Files: %d\n", stats.FilesDeleted)
+		fmt.Printf("- Directories: %d\n", stats.DirsDeleted)
+		fmt.Printf("- Freed: %s\n", reporter.FormatBytes(stats.BytesFreed))
+		for _, err := range stats.Errors {
+			fmt.Printf("- Error: %s\n", err)
+		}
+	}
+	if s.Error != "" {
+		fmt.Printf("- Error: %s\n", s.Error)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yourusername/rmrf/internal/reporter"
+)
+
+// printSummary writes the human-readable end-of-run report. After a dry
+// run it tells what would have been deleted rather than what was.
+func printSummary(stats *reporter.Stats, colors palette, dryRun, verbose, quiet bool) {
+	if dryRun {
+		fmt.Printf("\nWould delete:\n")
+	} else {
+		fmt.Printf("\nDeletion complete:\n")
+	}
+	fmt.Printf("- Files: %s\n", colors.green(fmt.Sprint(stats.FilesDeleted)))
+	fmt.Printf("- Directories: %s\n", colors.green(fmt.Sprint(stats.DirsDeleted)))
+	if stats.SymlinksRemoved > 0 {
+		fmt.Printf("- Symlinks: %s\n", colors.green(fmt.Sprint(stats.SymlinksRemoved)))
+	}
+	if len(stats.Trashed) > 0 {
+		moved := "Moved"
+		if dryRun {
+			moved = "Would move"
+		}
+		fmt.Printf("- %s to trash: %s\n", moved, colors.green(fmt.Sprint(len(stats.Trashed))))
+		if stats.OperationID != "" {
+			fmt.Printf("  undo with: rmrf restore %s\n", stats.OperationID)
+		}
+		if verbose {
+			for _, item := range stats.Trashed {
+				fmt.Printf("    %s -> %s\n", item.Original, item.TrashPath)
+			}
+		}
+	}
+	if len(stats.Roots) > 1 {
+		for _, root := range stats.Roots {
+			fmt.Printf("    %s: %d files, %d directories, %d errors\n",
+				root.Path, root.FilesDeleted, root.DirsDeleted, root.Errors)
+		}
+	}
+	if len(stats.Passes) > 1 {
+		fmt.Printf("- Passes: %d\n", len(stats.Passes))
+		for i, p := range stats.Passes {
+			fmt.Printf("    pass %d: %d files, %d directories, %d errors\n", i+1, p.Files, p.Dirs, p.Errors)
+		}
+	}
+	if verbose {
+		fs := stats.Filesystem
+		if fs == "" {
+			fs = "unknown"
+		}
+		if stats.ThreadsSettled > 0 {
+			fmt.Printf("- Filesystem: %s, %s profile (adaptive, up to %d threads, settled at %d)\n", fs, stats.Profile, stats.Threads, stats.ThreadsSettled)
+		} else {
+			fmt.Printf("- Filesystem: %s, %s profile (%d threads)\n", fs, stats.Profile, stats.Threads)
+		}
+	}
+	if stats.FilesDeleted > 0 {
+		freed := "Freed"
+		if dryRun {
+			freed = "Would free"
+		}
+		fmt.Printf("- %s: %s (apparent size %s)\n", freed,
+			colors.green(reporter.FormatBytes(stats.BytesFreed)), reporter.FormatBytes(stats.ApparentBytes))
+	}
+	if stats.LoadPaused > 0 {
+		fmt.Printf("- Paused for system load: %s\n", stats.LoadPaused.Round(time.Second))
+	}
+	if delta, ok := stats.FreeSpaceDelta(); ok {
+		fmt.Printf("- Filesystem free space %s\n", describeDelta(delta))
+	}
+	if stats.ManifestHash != "" {
+		fmt.Printf("- Manifest hash: %s\n", stats.ManifestHash)
+	}
+	if stats.Kept > 0 {
+		fmt.Printf("- Kept: %s\n", colors.amber(fmt.Sprint(stats.Kept)))
+	}
+	if len(stats.Skipped) > 0 {
+		fmt.Printf("- Skipped: %s\n", colors.amber(fmt.Sprint(len(stats.Skipped))))
+		for _, path := range stats.Skipped {
+			fmt.Printf("    %s\n", path)
+		}
+	}
+	if len(stats.Drift) > 0 {
+		fmt.Printf("- Not in the plan, kept: %s\n", colors.amber(fmt.Sprint(len(stats.Drift))))
+		for _, path := range stats.Drift {
+			fmt.Printf("    %s\n", path)
+		}
+	}
+	if len(stats.Pending) > 0 {
+		fmt.Printf("- Locked, deleted at next reboot: %s\n", colors.amber(fmt.Sprint(len(stats.Pending))))
+		for _, path := range stats.Pending {
+			fmt.Printf("    %s\n", path)
+		}
+	}
+	for _, name := range stats.Snapshots {
+		fmt.Printf("- Snapshot taken first: %s\n", name)
+	}
+	if stats.AttrProtected > 0 {
+		fmt.Printf("- Protected by immutable/append-only attributes: %s (--clear-attrs removes them)\n", colors.amber(fmt.Sprint(stats.AttrProtected)))
+	}
+	if stats.Subvolumes > 0 {
+		fmt.Printf("- Btrfs subvolumes skipped: %s (--delete-subvolumes removes them)\n", colors.amber(fmt.Sprint(stats.Subvolumes)))
+	}
+	if stats.OtherDevices > 0 {
+		fmt.Printf("- Other filesystems skipped: %s\n", colors.amber(fmt.Sprint(stats.OtherDevices)))
+	}
+	if stats.SymlinksSkipped > 0 {
+		fmt.Printf("- Symlinks skipped: %s\n", colors.amber(fmt.Sprint(stats.SymlinksSkipped)))
+	}
+
+	if !quiet {
+		for _, w := range stats.Warnings {
+			fmt.Printf("%s %s\n", colors.amber("note:"), w)
+		}
+	}
+
+	if len(stats.Errors) > 0 {
+		fmt.Printf("\n%s\n", colors.red(fmt.Sprintf("Encountered %d errors:", len(stats.Errors))))
+		for _, err := range stats.Errors {
+			fmt.Printf("  - %s\n", colors.red(err.Error()))
+		}
+	}
+}
+
+// printPlanEntry lists one entry of a dry run on stdout.
+func printPlanEntry(e reporter.PlanEntry) {
+	fmt.Printf("%-4s %10s  %s\n", e.Type, reporter.FormatBytes(e.Size), e.Path)
+}
+
+// describeDelta words a change in free space, which other writers to the
+// filesystem may have made negative despite the deletion.
+func describeDelta(delta int64) string {
+	switch {
+	case delta > 0:
+		return "increased by " + reporter.FormatBytes(delta)
+	case delta < 0:
+		return "decreased by " + reporter.FormatBytes(-delta)
+	}
+	return "unchanged"
+}
@@ -0,0 +1,20 @@
+package main
+
+import "testing"
+
+func TestDescribeDelta(t *testing.T) {
+	tests := []struct {
+		delta int64
+		want  string
+	}{
+		{2048, "increased by 2.0 KiB"},
+		{-2048, "decreased by 2.0 KiB"},
+		{-1, "decreased by 1 B"},
+		{0, "unchanged"},
+	}
+	for _, tt := range tests {
+		if got := describeDelta(tt.delta); got != tt.want {
+			t.Errorf("describeDelta(%d) = %q, want %q", tt.delta, got, tt.want)
+		}
+	}
+}
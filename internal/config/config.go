@@ -1,18 +1,116 @@
 package config
 
-import "runtime"
+import (
+	"io"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/yourusername/rmrf/internal/reporter"
+)
 
 type Options struct {
-	MaxThreads    int
-	DryRun        bool
-	Interactive   bool
-	Verbose       bool
-	SkipSymlinks  bool
-	DangerousPaths []string
+	MaxThreads         int
+	DryRun             bool
+	Interactive        bool
+	ConfirmEntries     int
+	ConfirmBytes       int64
+	ConfirmByName      bool
+	ConfirmNameBytes   int64
+	ConfirmSensitive   bool
+	Force              bool
+	AllowHome          bool
+	OneFileSystem      bool
+	AllowMountpoint    bool
+	Verbose            bool
+	SymlinkPolicy      SymlinkPolicy
+	MaxSymlinkHops     int
+	SpecialPolicy      SpecialPolicy
+	DangerousPaths     []string
+	ProtectedPaths     []string
+	EstimateFromStatfs bool
+	Estimate           bool
+	SampleProbes       int
+	AdaptiveLoad       float64
+	MaxPressure        float64
+	AdaptiveThreads    bool
+	RateEntries        float64
+	RateBytes          int64
+	SymlinkFarmRatio   float64
+	OnError            func(error)
+	Barrier            func(root string, stats *reporter.Stats) error
+	CompareFreeSpace   bool
+	IncludeOnly        []string
+	IncludeRegexps     []*regexp.Regexp
+	PreflightCheck     bool
+	PreflightAbort     bool
+	ResultHash         bool
+	PromptIn           io.Reader
+	PromptOut          io.Writer
+	Reporter           reporter.Reporter
+	NoGlob             bool
+	Excludes           []string
+	OlderThan          time.Duration
+	NewerThan          time.Duration
+	AgeTime            TimeField
+	LargerThan         int64
+	SmallerThan        int64
+	OwnerUIDs          []int
+	GroupGIDs          []int
+	MinDepth           int
+	MaxDepth           int
+	KeepRoot           bool
+	PruneEmpty         bool
+	GitClean           GitClean
+	Presets            []string
+	CacheDirsOnly      bool
+	SkipCacheDirs      bool
+	NestedIgnoreFiles  bool
+	Trash              bool
+	ShredPasses        int
+	Erasure            func(reporter.ErasureEntry)
+	ErasureHash        bool
+	AuditLog           string
+	Manifest           func(reporter.ManifestRow)
+	Archive            string
+	Snapshot           bool
+	DeleteSubvolumes   bool
+	Detach             bool
+	Handoff            func(targets []string) error
+	Failed             func(path string)
+	UntilEmpty         int
+	WatchWrites        bool
+	AbortOnWrites      bool
+	RetryRounds        int
+	RetryBackoff       time.Duration
+	Checkpoint         string
+	CheckpointArgs     []string
+	Resume             []string
+	Plan               func(reporter.PlanEntry)
+	Preview            []reporter.PlanEntry
+	Events             func(reporter.Event)
+	Root               *os.Root
+	Engine             Engine
+	Profile            Profile
+	ChmodPolicy        ChmodPolicy
+	ClearAttrs         bool
+	DeferLocked        bool
 }
 
 type Option func(*Options)
 
+// Engine selects how entries are removed.
+type Engine string
+
+const (
+	// EngineStandard makes one unlink or rmdir system call per entry.
+	EngineStandard Engine = "standard"
+	// EngineURing batches the removals of concurrent workers through an
+	// io_uring. It is experimental and needs Linux 5.11 or later; where
+	// it is unavailable the standard engine is used instead.
+	EngineURing Engine = "uring"
+)
+
 func WithMaxThreads(n int) Option {
 	return func(o *Options) {
 		o.MaxThreads = n
@@ -25,14 +123,861 @@ func WithDryRun(enabled bool) Option {
 	}
 }
 
+// WithInteractive asks, rm -i style, before removing each file and before
+// descending into each directory. Besides y and n, "a" answers yes to
+// everything that follows, "q" stops the run and "d" keeps the rest of
+// the current directory. Declined entries are kept and listed in
+// Stats.Skipped. Dry runs never prompt.
 func WithInteractive(enabled bool) Option {
 	return func(o *Options) {
 		o.Interactive = enabled
 	}
 }
 
+// WithConfirmLarge makes a run pre-scan its targets and, rm -I style, ask
+// once before deleting more than maxEntries entries or maxBytes bytes. The
+// prompt shows the totals and the largest part of each target's top level.
+// A zero limit is not checked. Dry runs never prompt.
+func WithConfirmLarge(maxEntries int, maxBytes int64) Option {
+	return func(o *Options) {
+		o.ConfirmEntries = maxEntries
+		o.ConfirmBytes = maxBytes
+	}
+}
+
+// WithConfirmByName makes a run ask for the name of a risky target to be
+// typed back before deleting anything: one that is or contains the home
+// directory, or lies within two components of the filesystem root, and,
+// if minBytes is positive, one holding more than minBytes bytes, which
+// takes a walk of every target to find out. A wrong or missing answer
+// fails the run with ErrNotConfirmed. Dry runs never prompt.
+func WithConfirmByName(minBytes int64) Option {
+	return func(o *Options) {
+		o.ConfirmByName = true
+		o.ConfirmNameBytes = minBytes
+	}
+}
+
+// WithConfirmSensitive makes a run by root look out for setuid or setgid
+// files and anything owned by another user while pre-scanning its targets
+// for WithConfirmLarge, WithEstimate or WithConfirmByName, and if it finds
+// any, list them and ask once before deleting anything. It adds no walk of
+// its own, so without a pre-scan it does nothing, and with
+// WithSampledEstimate it only sees the sampled directories. A no, or a
+// prompt input that is not a terminal, fails the run with ErrNotConfirmed.
+// Dry runs never prompt.
+func WithConfirmSensitive(enabled bool) Option {
+	return func(o *Options) {
+		o.ConfirmSensitive = enabled
+	}
+}
+
+// WithForce allows targets that are or contain the working directory or
+// the running executable, which are refused otherwise.
+func WithForce(enabled bool) Option {
+	return func(o *Options) {
+		o.Force = enabled
+	}
+}
+
+// WithAllowHome allows targets that are the home directory, an entry
+// directly in it, like ~/Documents, or an ancestor of it, which are
+// refused otherwise.
+func WithAllowHome(enabled bool) Option {
+	return func(o *Options) {
+		o.AllowHome = enabled
+	}
+}
+
+// WithOneFileSystem keeps any directory inside a target that is on a
+// different filesystem than the target itself, like a bind or NFS mount,
+// instead of deleting into it. Such directories are counted in
+// Stats.OtherDevices, and the directories containing them are kept.
+func WithOneFileSystem(enabled bool) Option {
+	return func(o *Options) {
+		o.OneFileSystem = enabled
+	}
+}
+
+// WithAllowMountpoint permits a target that is itself a mount point. By
+// default such targets are refused, since emptying a mounted filesystem is
+// rarely what was meant.
+func WithAllowMountpoint(enabled bool) Option {
+	return func(o *Options) {
+		o.AllowMountpoint = enabled
+	}
+}
+
+// WithRoot confines the run to root, which every target must lie within.
+// Directories are opened and entries changed and removed through root, so
+// the OS refuses anything a crafted symlink or rename would lead outside
+// it. The caller keeps ownership of root and closes it after the run.
+// Trash mode cannot be combined with it.
+func WithRoot(root *os.Root) Option {
+	return func(o *Options) {
+		o.Root = root
+	}
+}
+
+// Profile tunes a run to the kind of filesystem its targets are on.
+type Profile string
+
+const (
+	// ProfileAuto picks ProfileNetwork for targets on NFS, CIFS/SMB or
+	// sshfs, and ProfileLocal for anything else.
+	ProfileAuto Profile = "auto"
+	// ProfileLocal sizes the workers to the CPUs, more or fewer depending
+	// on the filesystem, and retries after short waits.
+	ProfileLocal Profile = "local"
+	// ProfileNetwork is for latency-bound filesystems, where most of a
+	// removal is spent waiting for the server: it runs many more workers,
+	// and waits longer before retries for files others hold open.
+	ProfileNetwork Profile = "network"
+)
+
+// WithProfile selects how the worker count, unless set with
+// WithMaxThreads, and the retry backoff, unless set with WithRetries, are
+// tuned. The default is ProfileAuto.
+func WithProfile(profile Profile) Option {
+	return func(o *Options) {
+		o.Profile = profile
+	}
+}
+
+// WithEngine selects the engine that removes entries. The default is
+// EngineStandard.
+func WithEngine(engine Engine) Option {
+	return func(o *Options) {
+		o.Engine = engine
+	}
+}
+
+// ChmodPolicy says when a run may change permissions to be able to delete
+// something.
+type ChmodPolicy int
+
+const (
+	// ChmodOnFailure makes directories writable and searchable when they
+	// are not, and changes the mode of anything whose removal is refused
+	// before trying again. It is the default.
+	ChmodOnFailure ChmodPolicy = iota
+	// ChmodNever leaves permissions alone; entries that can't be removed
+	// as they are are reported as errors.
+	ChmodNever
+	// ChmodAlways changes the mode of every entry before removing it,
+	// whether that is needed or not.
+	ChmodAlways
+)
+
+// SymlinkPolicy says what a run does with the symbolic links it comes
+// across, targets included. On Windows that covers every reparse point,
+// junctions among them.
+type SymlinkPolicy int
+
+const (
+	// SymlinkUnlink removes the link itself and never looks at what it
+	// points to, like rm -r. It is the default.
+	SymlinkUnlink SymlinkPolicy = iota
+	// SymlinkSkip leaves links in place and reports each one, which keeps
+	// the directories holding them too.
+	SymlinkSkip
+	// SymlinkFollow deletes the contents of the directories links point
+	// to, then the links. The directories themselves are left, as are
+	// the targets of links to anything else. A directory is never entered
+	// twice, so links that loop back are only unlinked, as are links into
+	// the target being deleted, which is cleared anyway, links to another
+	// filesystem than the target's, and links reached through more than
+	// MaxSymlinkHops others. Links are not followed in a run confined
+	// with WithRoot.
+	SymlinkFollow
+)
+
+// WithSymlinkPolicy sets what is done with symbolic links, see
+// SymlinkPolicy.
+func WithSymlinkPolicy(policy SymlinkPolicy) Option {
+	return func(o *Options) {
+		o.SymlinkPolicy = policy
+	}
+}
+
+// WithMaxSymlinkHops sets how many followed symlinks deep SymlinkFollow
+// goes: a link inside a directory reached through n links is only
+// unlinked once n is max. The default is 8.
+func WithMaxSymlinkHops(max int) Option {
+	return func(o *Options) {
+		o.MaxSymlinkHops = max
+	}
+}
+
+// SpecialPolicy says what a run does with special files: FIFOs, sockets
+// and device nodes. Whatever it is, they are never opened or chmod'ed,
+// which for a device could act on the device itself.
+type SpecialPolicy int
+
+const (
+	// SpecialDelete removes special files like any other file. It is the
+	// default.
+	SpecialDelete SpecialPolicy = iota
+	// SpecialSkip leaves special files, and the directories holding them,
+	// in place, counting them as kept.
+	SpecialSkip
+	// SpecialError leaves special files in place too, but reports each as
+	// an ErrSpecialFile error.
+	SpecialError
+)
+
+// WithSpecialPolicy sets what is done with special files, see
+// SpecialPolicy.
+func WithSpecialPolicy(policy SpecialPolicy) Option {
+	return func(o *Options) {
+		o.SpecialPolicy = policy
+	}
+}
+
+// WithChmodPolicy sets when permissions may be changed, see ChmodPolicy.
+func WithChmodPolicy(policy ChmodPolicy) Option {
+	return func(o *Options) {
+		o.ChmodPolicy = policy
+	}
+}
+
+// WithNoChmod is short for WithChmodPolicy(ChmodNever) when enabled.
+func WithNoChmod(enabled bool) Option {
+	return func(o *Options) {
+		if enabled {
+			o.ChmodPolicy = ChmodNever
+		}
+	}
+}
+
+// WithClearAttrs clears the immutable and append-only attributes, like
+// chattr -i -a, of entries that can't be removed because of them, and of
+// the directories holding them, then tries again. That takes root or
+// CAP_LINUX_IMMUTABLE. Without it such entries are counted in
+// Stats.AttrProtected and reported as ErrAttrProtected errors. Only Linux
+// has these attributes.
+func WithClearAttrs(enabled bool) Option {
+	return func(o *Options) {
+		o.ClearAttrs = enabled
+	}
+}
+
+// WithDeferLocked schedules files that another process has open, which
+// Windows will not delete, to be deleted at the next reboot instead, along
+// with the directories holding them. That takes administrator rights.
+// They are listed in Stats.Pending. Other platforms never lock files.
+func WithDeferLocked(enabled bool) Option {
+	return func(o *Options) {
+		o.DeferLocked = enabled
+	}
+}
+
 func WithVerbose(enabled bool) Option {
 	return func(o *Options) {
 		o.Verbose = enabled
 	}
 }
+
+// WithDangerousPaths replaces the list of paths that may never be deleted.
+// A target is refused if it is one of these paths or an ancestor of one.
+func WithDangerousPaths(paths []string) Option {
+	return func(o *Options) {
+		o.DangerousPaths = paths
+	}
+}
+
+// WithProtectedPaths adds glob patterns for absolute paths that may never
+// be deleted. Like DangerousPaths, a target is refused if it matches a
+// pattern or contains something that could, so "/home/*" protects every
+// home directory and /home itself. A pattern ending in "/**" protects
+// the whole subtree below its matches as well.
+func WithProtectedPaths(patterns ...string) Option {
+	return func(o *Options) {
+		o.ProtectedPaths = append(o.ProtectedPaths, patterns...)
+	}
+}
+
+// WithEntryCountEstimateFromStatfs seeds the progress total from the number
+// of used inodes on the target's filesystem instead of starting at zero.
+// The figure is approximate and only sensible when the target makes up most
+// of its filesystem; it is ignored where statfs is unavailable.
+func WithEntryCountEstimateFromStatfs(enabled bool) Option {
+	return func(o *Options) {
+		o.EstimateFromStatfs = enabled
+	}
+}
+
+// WithEstimate walks every target before deleting anything to count its
+// entries, so progress has a real total to show a percentage and ETA
+// against. This costs a full extra traversal; it takes precedence over
+// WithEntryCountEstimateFromStatfs.
+func WithEstimate(enabled bool) Option {
+	return func(o *Options) {
+		o.Estimate = enabled
+	}
+}
+
+// WithSampledEstimate makes the pre-scans of WithEstimate and
+// WithConfirmLarge sample the tree instead of walking all of it, for
+// trees too big to count in reasonable time. probes random root-to-leaf
+// walks are extrapolated into totals with a 95% confidence interval; more
+// probes narrow the interval. Zero walks the whole tree.
+func WithSampledEstimate(probes int) Option {
+	return func(o *Options) {
+		o.SampleProbes = probes
+	}
+}
+
+// WithAdaptiveLoad shrinks the number of concurrent workers while the
+// 1-minute load average is above targetLoad, down to pausing the run if
+// one is still too many, and grows it back when the system is idle. This
+// is a heuristic meant for background cleanups and is a no-op except on
+// Linux and macOS, which have a load average to read. Zero disables it.
+func WithAdaptiveLoad(targetLoad float64) Option {
+	return func(o *Options) {
+		o.AdaptiveLoad = targetLoad
+	}
+}
+
+// WithMaxPressure is WithAdaptiveLoad going by IO pressure instead: the
+// share of the last 10 seconds in which some task waited on IO, in
+// percent, from /proc/pressure/io. It follows the load much faster than
+// the load average and only counts IO. It needs Linux with PSI and is a
+// no-op elsewhere. With both, the workers shrink while either is over.
+// Zero disables it.
+func WithMaxPressure(percent float64) Option {
+	return func(o *Options) {
+		o.MaxPressure = percent
+	}
+}
+
+// WithAdaptiveThreads varies the number of active workers during the run,
+// up to MaxThreads or, if that is zero, 64, to remove the most entries per
+// second: it measures the latency and error rate of removals and adds
+// workers while that pays, and drops them when it stops paying or the
+// device saturates, as on spinning disks, where many workers seeking at
+// once are slower than a few. Stats.ThreadsSettled is the count it ended
+// at.
+func WithAdaptiveThreads(enabled bool) Option {
+	return func(o *Options) {
+		o.AdaptiveThreads = enabled
+	}
+}
+
+// WithRateLimit paces removals to at most filesPerSec entries, files and
+// directories alike, and bytesPerSec bytes of regular files a second,
+// with a token bucket each allowing a second's worth in a burst, so a
+// large cleanup on a busy host leaves IO for everything else. Zero leaves
+// a rate unlimited. Dry runs are never slowed.
+func WithRateLimit(filesPerSec float64, bytesPerSec int64) Option {
+	return func(o *Options) {
+		o.RateEntries = filesPerSec
+		o.RateBytes = bytesPerSec
+	}
+}
+
+// WithSymlinkFarmRatio sets the fraction of skipped symlinks among all
+// entries above which the run is flagged as a possible symlink farm.
+// Zero disables the check.
+func WithSymlinkFarmRatio(ratio float64) Option {
+	return func(o *Options) {
+		o.SymlinkFarmRatio = ratio
+	}
+}
+
+// WithOnError registers an observer that is called with every error as it
+// is recorded, for live monitoring. The callback runs while the stats lock
+// is held, so it must be fast and must not block; it cannot stop the run.
+func WithOnError(fn func(error)) Option {
+	return func(o *Options) {
+		o.OnError = fn
+	}
+}
+
+// WithBarrier installs a hook that runs after everything inside a target
+// has been deleted but before the target directory itself is removed.
+// Returning an error keeps the (now empty) target and makes Delete return
+// that error along with the stats so far. Note that the contents are
+// already gone by the time the hook runs; only the final removal waits.
+// Other targets may still be running, so read stats through Snapshot.
+func WithBarrier(fn func(root string, stats *reporter.Stats) error) Option {
+	return func(o *Options) {
+		o.Barrier = fn
+	}
+}
+
+// WithDeleteSummaryCompare records the free space on the target's
+// filesystem before and after the run so the summary can show how much
+// space was actually reclaimed. Ignored where statfs is unavailable.
+func WithDeleteSummaryCompare(enabled bool) Option {
+	return func(o *Options) {
+		o.CompareFreeSpace = enabled
+	}
+}
+
+// WithIncludeOnly restricts deletion to files matching one of the glob
+// patterns. Everything else is kept, along with the directories that
+// contain it. Patterns without a slash match the file name at any depth;
+// patterns with one match the path relative to the target. Excludes are
+// applied after this filter.
+func WithIncludeOnly(patterns ...string) Option {
+	return func(o *Options) {
+		o.IncludeOnly = append(o.IncludeOnly, patterns...)
+	}
+}
+
+// WithIncludeRegexps restricts deletion to files matching one of the
+// regular expressions, in addition to those WithIncludeOnly picks if both
+// are given. Like the glob patterns, expressions without a slash are
+// matched against the file name and ones with a slash against the path
+// relative to the target, always with slashes as separators. They are
+// unanchored, so `\.o$` picks object files at any depth.
+func WithIncludeRegexps(res ...*regexp.Regexp) Option {
+	return func(o *Options) {
+		o.IncludeRegexps = append(o.IncludeRegexps, res...)
+	}
+}
+
+// WithPreflightCheck samples the target before deleting anything and
+// warns if the current user probably cannot delete part of it.
+func WithPreflightCheck(enabled bool) Option {
+	return func(o *Options) {
+		o.PreflightCheck = enabled
+	}
+}
+
+// WithPreflightAbort makes a failed preflight check abort the run instead
+// of only warning. It implies WithPreflightCheck.
+func WithPreflightAbort(enabled bool) Option {
+	return func(o *Options) {
+		o.PreflightAbort = enabled
+		if enabled {
+			o.PreflightCheck = true
+		}
+	}
+}
+
+// WithResultHash computes the SHA-256 of the sorted paths and sizes
+// of everything deleted and stores it in Stats.ManifestHash, so a recorded
+// manifest can later be checked against it. See reporter.ManifestHasher.
+func WithResultHash(enabled bool) Option {
+	return func(o *Options) {
+		o.ResultHash = enabled
+	}
+}
+
+// WithPrompt sets where interactive confirmations are read from and
+// written to. It defaults to stdin and stdout.
+func WithPrompt(in io.Reader, out io.Writer) Option {
+	return func(o *Options) {
+		o.PromptIn = in
+		o.PromptOut = out
+	}
+}
+
+// WithReporter sets how progress is displayed. The default redraws a
+// progress line on stdout; use reporter.NoopReporter{} for silence or
+// reporter.NewJSONReporter for a machine-readable stream.
+func WithReporter(r reporter.Reporter) Option {
+	return func(o *Options) {
+		o.Reporter = r
+	}
+}
+
+// WithNoGlob treats every target literally, for paths that contain glob
+// metacharacters in their names.
+func WithNoGlob(enabled bool) Option {
+	return func(o *Options) {
+		o.NoGlob = enabled
+	}
+}
+
+// WithExcludes keeps files and whole directories matching any of the glob
+// patterns, using the same matching rules as WithIncludeOnly. Directories
+// left non-empty because of a kept entry are kept as well and counted in
+// Stats.Kept rather than reported as errors.
+func WithExcludes(patterns []string) Option {
+	return func(o *Options) {
+		o.Excludes = append(o.Excludes, patterns...)
+	}
+}
+
+// TimeField selects the timestamp the age filters go by.
+type TimeField string
+
+const (
+	TimeModified TimeField = "mtime" // last change of the contents
+	TimeAccessed TimeField = "atime" // last read, if the mount records it
+	TimeChanged  TimeField = "ctime" // last change of the contents or metadata
+)
+
+// WithOlderThan restricts deletion to files whose timestamp, see
+// WithAgeTime, is more than age before the start of the run. Everything
+// else is kept, along with the directories that contain it, as with
+// WithIncludeOnly.
+func WithOlderThan(age time.Duration) Option {
+	return func(o *Options) {
+		o.OlderThan = age
+	}
+}
+
+// WithNewerThan restricts deletion to files whose timestamp is less than
+// age before the start of the run, like WithOlderThan.
+func WithNewerThan(age time.Duration) Option {
+	return func(o *Options) {
+		o.NewerThan = age
+	}
+}
+
+// WithAgeTime sets the timestamp WithOlderThan and WithNewerThan compare.
+// The default is TimeModified. Where a platform lacks the one asked for,
+// the modification time is used.
+func WithAgeTime(field TimeField) Option {
+	return func(o *Options) {
+		o.AgeTime = field
+	}
+}
+
+// WithLargerThan restricts deletion to files of more than size bytes.
+// Everything else is kept, along with the directories that contain it,
+// as with WithIncludeOnly.
+func WithLargerThan(size int64) Option {
+	return func(o *Options) {
+		o.LargerThan = size
+	}
+}
+
+// WithSmallerThan restricts deletion to files of fewer than size bytes,
+// like WithLargerThan.
+func WithSmallerThan(size int64) Option {
+	return func(o *Options) {
+		o.SmallerThan = size
+	}
+}
+
+// WithOwners restricts deletion to files owned by one of the user IDs.
+// Everything else is kept, along with the directories that contain it,
+// as with WithIncludeOnly. Where files have no Unix owner, on Windows,
+// everything is kept.
+func WithOwners(uids ...int) Option {
+	return func(o *Options) {
+		o.OwnerUIDs = append(o.OwnerUIDs, uids...)
+	}
+}
+
+// WithGroups restricts deletion to files whose group is one of the group
+// IDs, like WithOwners.
+func WithGroups(gids ...int) Option {
+	return func(o *Options) {
+		o.GroupGIDs = append(o.GroupGIDs, gids...)
+	}
+}
+
+// WithMinDepth keeps everything less than depth levels below the target,
+// like find -mindepth: the target is at depth 0 and its entries at 1.
+// Directories above the limit are still cleared, of what is deep enough.
+func WithMinDepth(depth int) Option {
+	return func(o *Options) {
+		o.MinDepth = depth
+	}
+}
+
+// WithMaxDepth keeps everything more than depth levels below the target,
+// like find -maxdepth, so directories at the limit are removed only if
+// they are empty. Zero means no limit.
+func WithMaxDepth(depth int) Option {
+	return func(o *Options) {
+		o.MaxDepth = depth
+	}
+}
+
+// WithKeepRoot deletes everything inside directory targets, dotfiles
+// included, but leaves the targets themselves, like rm -rf dir/* would if
+// globs matched hidden names. Targets that are files are deleted as
+// usual.
+func WithKeepRoot(enabled bool) Option {
+	return func(o *Options) {
+		o.KeepRoot = enabled
+	}
+}
+
+// WithPruneEmpty removes only directories that are empty, or hold nothing
+// but empty directories, and keeps everything else: files, symlinks and
+// the directories that contain them. A target that is empty once pruned is
+// removed too.
+func WithPruneEmpty(enabled bool) Option {
+	return func(o *Options) {
+		o.PruneEmpty = enabled
+	}
+}
+
+// GitClean selects which files of a git work tree a run deletes.
+type GitClean string
+
+const (
+	// GitIgnored deletes the files git ignores, like git clean -fdX.
+	GitIgnored GitClean = "ignored"
+	// GitUntracked deletes every file git does not track, ignored ones
+	// included, like git clean -fdx.
+	GitUntracked GitClean = "untracked"
+)
+
+// WithGitClean turns each target, which must be a directory in a git work
+// tree, into the files and directories below it that git lists for mode,
+// and deletes those instead. Nested repositories are left alone. The empty
+// mode, the default, turns this off.
+func WithGitClean(mode GitClean) Option {
+	return func(o *Options) {
+		o.GitClean = mode
+	}
+}
+
+// WithPresets turns each target into the directories below it that one of
+// the named presets, see Presets, recognizes as build output or caches,
+// and deletes those instead. Recognized directories are not searched any
+// further, and neither are symlinks.
+func WithPresets(names ...string) Option {
+	return func(o *Options) {
+		o.Presets = append(o.Presets, names...)
+	}
+}
+
+// WithCacheDirsOnly turns each target into the directories in it, itself
+// included, that are marked as caches with a CACHEDIR.TAG file, see
+// https://bford.info/cachedir/, and deletes those instead. Marked
+// directories are not searched any further, and neither are symlinks.
+func WithCacheDirsOnly(enabled bool) Option {
+	return func(o *Options) {
+		o.CacheDirsOnly = enabled
+	}
+}
+
+// WithSkipCacheDirs keeps directories marked with a CACHEDIR.TAG file, and
+// everything in them, the way backup tools leave them out.
+func WithSkipCacheDirs(enabled bool) Option {
+	return func(o *Options) {
+		o.SkipCacheDirs = enabled
+	}
+}
+
+// WithNestedIgnoreFiles honors an .rmrfignore file in every directory,
+// not just in the targets. Its patterns, in gitignore syntax, protect the
+// matching paths below that directory from deletion, on top of those of
+// the directories above it.
+func WithNestedIgnoreFiles(enabled bool) Option {
+	return func(o *Options) {
+		o.NestedIgnoreFiles = enabled
+	}
+}
+
+// WithShred overwrites every regular file passes times, with random data
+// and, if passes is more than one, a last pass of zeros, syncing after
+// each, before removing it. Targets on a copy-on-write filesystem or a
+// solid-state drive, where that is no guarantee, are deleted without it
+// and with a warning, and so are files with other hard links. A file that
+// can't be overwritten is reported and left in place.
+func WithShred(passes int) Option {
+	return func(o *Options) {
+		o.ShredPasses = passes
+	}
+}
+
+// WithErasureRecord calls fn, one call at a time, with every entry a run
+// deletes, for a reporter.ErasureReport. With hash, each regular file is
+// read and its SHA-256 recorded before it is removed; a file that can't
+// be read is reported and left in place.
+func WithErasureRecord(fn func(reporter.ErasureEntry), hash bool) Option {
+	return func(o *Options) {
+		o.Erasure = fn
+		o.ErasureHash = hash
+	}
+}
+
+// WithAuditLog appends a line to the file at path for every entry a run
+// removes or trashes, whatever the verbosity: the time, the user and
+// process IDs, the kind of removal and the path. Lines are written as the
+// removals happen, each under an exclusive lock on the file so that runs
+// sharing it don't mix them up. Dry runs don't log.
+func WithAuditLog(path string) Option {
+	return func(o *Options) {
+		o.AuditLog = path
+	}
+}
+
+// WithManifest calls fn, possibly concurrently, for every entry a run
+// deletes, skips or fails on, with its type, size and mtime as they were
+// before, for reporter.ManifestCSV. Errors that are not about an entry,
+// like a failed hook, have no row.
+func WithManifest(fn func(reporter.ManifestRow)) Option {
+	return func(o *Options) {
+		o.Manifest = fn
+	}
+}
+
+// WithArchive writes everything a run deletes into a new tar archive at
+// path before removing it, so it can be extracted again: each entry is
+// written out in full before it is removed, and one that can't be is
+// reported and kept. The name picks the compression: .tar.zst or .tzst
+// (with the zstd command), .tar.gz or .tgz, or .tar for none. Entries are
+// named relative to their target's parent, and the archive may not be
+// inside a target. Dry runs don't archive.
+func WithArchive(path string) Option {
+	return func(o *Options) {
+		o.Archive = path
+	}
+}
+
+// WithSnapshot takes a read-only snapshot of the btrfs subvolume or ZFS
+// dataset holding each target before deleting anything, so the deletion
+// can be undone from it; their names are in Stats.Snapshots. A btrfs
+// snapshot is made inside its subvolume as .rmrf-<time>, or next to it if
+// a target would delete it. A target on any other filesystem, or a
+// failed snapshot, fails the run. Dry runs take none.
+func WithSnapshot(enabled bool) Option {
+	return func(o *Options) {
+		o.Snapshot = enabled
+	}
+}
+
+// WithDeleteSubvolumes deletes the nested btrfs subvolumes found inside
+// targets, once emptied, with the subvolume-delete ioctl, which needs root
+// or a filesystem mounted with user_subvol_rm_allowed; rmdir can't remove
+// them. Without it they are kept and counted in Stats.Subvolumes. A target
+// that is itself a subvolume is treated the same way.
+func WithDeleteSubvolumes(enabled bool) Option {
+	return func(o *Options) {
+		o.DeleteSubvolumes = enabled
+	}
+}
+
+// WithDetach renames each target aside, to a hidden name in the same
+// directory, before deleting it, so the path is free for a replacement at
+// once and the rename, on one filesystem, is atomic. It runs after every
+// check and confirmation, and cannot be combined with filters, interactive
+// mode, archiving or trash mode.
+func WithDetach(enabled bool) Option {
+	return func(o *Options) {
+		o.Detach = enabled
+	}
+}
+
+// WithHandoff ends runs once the targets have passed every check and
+// confirmation, been snapshotted and, with WithDetach, moved aside, giving
+// them to handoff to delete instead, in another process for instance. The
+// run returns what handoff does. Dry runs don't hand off.
+func WithHandoff(handoff func(targets []string) error) Option {
+	return func(o *Options) {
+		o.Handoff = handoff
+	}
+}
+
+// WithFailedPaths calls fn with the path of every entry that could not
+// be deleted, as its error is recorded, so that they can be tried again
+// without walking the whole tree. It may be called concurrently, and more
+// than once for a path.
+func WithFailedPaths(fn func(path string)) Option {
+	return func(o *Options) {
+		o.Failed = fn
+	}
+}
+
+// WithUntilEmpty walks the targets again after a pass if entries
+// appeared in directories while it was deleting them, as with processes
+// still writing logs, deleting those, for at most passes passes in all.
+// Directories found not empty are only reported as errors on the last
+// pass, which is the one after a pass that deleted nothing if sooner.
+// Stats.Passes has the counts of each pass. Zero or one means a single
+// pass.
+func WithUntilEmpty(passes int) Option {
+	return func(o *Options) {
+		o.UntilEmpty = passes
+	}
+}
+
+// WithWatchWrites watches the targets while they are being deleted for
+// entries that another process creates in them, using inotify on Linux,
+// and reports them with a warning: whatever writes there may still be
+// running, and is why a directory could not be removed. With abort, the
+// first one stops the run with deleter.ErrActiveWriter instead. abort
+// implies watch. Only abort is an error where watching is unsupported.
+func WithWatchWrites(watch, abort bool) Option {
+	return func(o *Options) {
+		o.WatchWrites = watch || abort
+		o.AbortOnWrites = abort
+	}
+}
+
+// WithRetries tries entries whose removal failed with a transient error
+// (EBUSY, ETXTBSY, EAGAIN, or a file locked by another process on
+// Windows) again at the end of the run, in up to rounds rounds, waiting
+// backoff before the first and twice as long before each next one, rather
+// than reporting them at once. The directories holding them wait with
+// them. Zero rounds disables retrying, and a zero backoff is the one of
+// the Profile.
+func WithRetries(rounds int, backoff time.Duration) Option {
+	return func(o *Options) {
+		o.RetryRounds = rounds
+		o.RetryBackoff = backoff
+	}
+}
+
+// WithCheckpoint saves the traversal state of runs to a checkpoint file
+// at path every few seconds, and when a run is interrupted or ends with
+// errors: the subtrees done with, everything left in them kept by filters
+// or otherwise, and the directories still pending. args, the command line,
+// is saved with it. A run that completes removes it. See WithResume.
+func WithCheckpoint(path string, args []string) Option {
+	return func(o *Options) {
+		o.Checkpoint = path
+		o.CheckpointArgs = args
+	}
+}
+
+// WithResume skips the completed subtrees of a checkpoint, see
+// WithCheckpoint, keeping them without listing them again, so that an
+// interrupted run can go on where it stopped.
+func WithResume(completed []string) Option {
+	return func(o *Options) {
+		o.Resume = completed
+	}
+}
+
+// WithTrash moves each target into the platform trash instead of deleting
+// it. Targets are moved whole, so this cannot be combined with filters or
+// interactive mode.
+func WithTrash(enabled bool) Option {
+	return func(o *Options) {
+		o.Trash = enabled
+	}
+}
+
+// WithPlan calls fn for every file and directory the run deletes, or
+// would delete in a dry run, with its size, mtime and identity. Calls are
+// serialized. reporter.NewPlanJSONWriter produces a plan file.
+func WithPlan(fn func(reporter.PlanEntry)) Option {
+	return func(o *Options) {
+		o.Plan = fn
+	}
+}
+
+// WithResultDiffAgainstPreview checks the run against plan, what an
+// earlier dry run with WithPlan listed. An entry not in it, one the tree
+// gained since, is kept and listed in Stats.Drift, and so are the
+// directories holding it, so nothing added after the plan was reviewed is
+// deleted. Deleter.Apply always checks against its plan this way.
+func WithResultDiffAgainstPreview(plan []reporter.PlanEntry) Option {
+	return func(o *Options) {
+		o.Preview = plan
+	}
+}
+
+// WithEvents streams what happens during a run as it happens: every
+// deleted, trashed and skipped path and every error. fn is called from the
+// deleting goroutines and must be safe for concurrent use, as the writer
+// from reporter.NewEventJSONWriter is. Progress events come from the
+// Reporter instead, see reporter.EventReporter.
+func WithEvents(fn func(reporter.Event)) Option {
+	return func(o *Options) {
+		o.Events = fn
+	}
+}
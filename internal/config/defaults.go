@@ -1,10 +1,16 @@
 package config
 
 var DefaultOptions = Options{
-	MaxThreads:    runtime.NumCPU(),
-	DryRun:        false,
-	Interactive:   false,
-	Verbose:       false,
-	SkipSymlinks:  true,
-	DangerousPaths: []string{"/", "/etc", "/usr", "/bin", "/sbin"},
+	MaxThreads:       0, // tuned to the target's filesystem, see deleter.tune
+	DryRun:           false,
+	Interactive:      false,
+	Verbose:          false,
+	DangerousPaths:   []string{"/", "/etc", "/usr", "/bin", "/sbin"},
+	SymlinkFarmRatio: 0.9,
+	MaxSymlinkHops:   8,
+	Engine:           EngineStandard,
+	Profile:          ProfileAuto,
+	AgeTime:          TimeModified,
+	RetryRounds:      3,
+	RetryBackoff:     0, // tuned with the Profile, see deleter.tune
 }
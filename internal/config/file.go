@@ -0,0 +1,209 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// SystemConfigFile holds site-wide defaults.
+const SystemConfigFile = "/etc/rmrf/config.toml"
+
+// File holds the defaults a config file may set. Unset scalars are nil so
+// a later layer can tell them apart from zero values.
+//
+// Settings are merged from lowest to highest precedence: built-in
+// defaults, SystemConfigFile, the user's config.toml, RMRF_* environment
+// variables (see LoadEnv), and then command line flags. Scalars from a higher layer replace lower ones; lists
+// (excludes, protected paths) accumulate.
+type File struct {
+	Threads        *int
+	DryRun         *bool
+	Trash          *bool
+	Verbose        *bool
+	OneFileSystem  *bool
+	Color          *string
+	Format         *string
+	Excludes       []string
+	ProtectedPaths []string
+}
+
+// LoadConfigFiles reads SystemConfigFile and then the user's
+// $XDG_CONFIG_HOME/rmrf/config.toml, the latter taking precedence.
+// Missing files are not an error.
+func LoadConfigFiles() (File, error) {
+	files := []string{SystemConfigFile}
+	if dir, err := UserConfigDir(); err == nil {
+		files = append(files, filepath.Join(dir, "config.toml"))
+	}
+
+	var merged File
+	for _, path := range files {
+		f, err := ReadConfigFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return File{}, err
+		}
+		merged.merge(f)
+	}
+	return merged, nil
+}
+
+// LoadEnv layers RMRF_* environment variables over the config files, for
+// CI pipelines and containers:
+//
+//	RMRF_MAX_THREADS      threads
+//	RMRF_DRY_RUN          true or false
+//	RMRF_TRASH            true or false
+//	RMRF_VERBOSE          true or false
+//	RMRF_ONE_FILE_SYSTEM  true or false
+//	RMRF_COLOR            auto, always or never
+//	RMRF_FORMAT           text or logfmt
+//	RMRF_EXCLUDES         globs, separated like PATH
+//	RMRF_PROTECTED_PATHS  globs, separated like PATH
+//
+// Empty variables are ignored.
+func LoadEnv(f File) (File, error) {
+	var env File
+	var errs []error
+	envInt := func(name string) *int {
+		v := os.Getenv(name)
+		if v == "" {
+			return nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid number %q", name, v))
+			return nil
+		}
+		return &n
+	}
+	envBool := func(name string) *bool {
+		v := os.Getenv(name)
+		if v == "" {
+			return nil
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid boolean %q", name, v))
+			return nil
+		}
+		return &b
+	}
+	envString := func(name string) *string {
+		if v := os.Getenv(name); v != "" {
+			return &v
+		}
+		return nil
+	}
+	envList := func(name string) []string {
+		if v := os.Getenv(name); v != "" {
+			return filepath.SplitList(v)
+		}
+		return nil
+	}
+
+	env.Threads = envInt("RMRF_MAX_THREADS")
+	env.DryRun = envBool("RMRF_DRY_RUN")
+	env.Trash = envBool("RMRF_TRASH")
+	env.Verbose = envBool("RMRF_VERBOSE")
+	env.OneFileSystem = envBool("RMRF_ONE_FILE_SYSTEM")
+	env.Color = envString("RMRF_COLOR")
+	env.Format = envString("RMRF_FORMAT")
+	env.Excludes = envList("RMRF_EXCLUDES")
+	env.ProtectedPaths = envList("RMRF_PROTECTED_PATHS")
+	if err := errors.Join(errs...); err != nil {
+		return File{}, err
+	}
+
+	f.merge(env)
+	return f, nil
+}
+
+// ReadConfigFile parses a single config file.
+func ReadConfigFile(path string) (File, error) {
+	r, err := os.Open(path)
+	if err != nil {
+		return File{}, err
+	}
+	defer r.Close()
+
+	values, err := parseTOML(r)
+	if err != nil {
+		return File{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var f File
+	for key, value := range values {
+		var ok bool
+		switch key {
+		case "threads":
+			var n int64
+			if n, ok = value.(int64); ok {
+				threads := int(n)
+				f.Threads = &threads
+			}
+		case "trash":
+			f.Trash, ok = boolValue(value)
+		case "verbose":
+			f.Verbose, ok = boolValue(value)
+		case "one_file_system":
+			f.OneFileSystem, ok = boolValue(value)
+		case "color":
+			f.Color, ok = stringValue(value)
+		case "format":
+			f.Format, ok = stringValue(value)
+		case "excludes":
+			f.Excludes, ok = value.([]string)
+		case "protected_paths":
+			f.ProtectedPaths, ok = value.([]string)
+		default:
+			return File{}, fmt.Errorf("%s: unknown setting %q", path, key)
+		}
+		if !ok {
+			return File{}, fmt.Errorf("%s: %s has the wrong type", path, key)
+		}
+	}
+	return f, nil
+}
+
+// merge layers o over f.
+func (f *File) merge(o File) {
+	if o.Threads != nil {
+		f.Threads = o.Threads
+	}
+	if o.DryRun != nil {
+		f.DryRun = o.DryRun
+	}
+	if o.Trash != nil {
+		f.Trash = o.Trash
+	}
+	if o.Verbose != nil {
+		f.Verbose = o.Verbose
+	}
+	if o.OneFileSystem != nil {
+		f.OneFileSystem = o.OneFileSystem
+	}
+	if o.Color != nil {
+		f.Color = o.Color
+	}
+	if o.Format != nil {
+		f.Format = o.Format
+	}
+	f.Excludes = append(f.Excludes, o.Excludes...)
+	f.ProtectedPaths = append(f.ProtectedPaths, o.ProtectedPaths...)
+}
+
+func boolValue(v any) (*bool, bool) {
+	b, ok := v.(bool)
+	return &b, ok
+}
+
+func stringValue(v any) (*string, bool) {
+	s, ok := v.(string)
+	return &s, ok
+}
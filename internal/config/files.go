@@ -0,0 +1,64 @@
+package config
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SystemProtectedFile is the administrator's list of protected paths.
+const SystemProtectedFile = "/etc/rmrf/protected"
+
+// ReadPatternFile reads one pattern per line, ignoring blank lines and
+// lines starting with "#".
+func ReadPatternFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, scanner.Err()
+}
+
+// UserConfigDir is $XDG_CONFIG_HOME/rmrf, falling back to ~/.config/rmrf.
+func UserConfigDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "rmrf"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "rmrf"), nil
+}
+
+// LoadProtectedPaths reads the protected path patterns from the system
+// list and the user's $XDG_CONFIG_HOME/rmrf/protected, in that order.
+// Missing files are not an error.
+func LoadProtectedPaths() ([]string, error) {
+	files := []string{SystemProtectedFile}
+	if dir, err := UserConfigDir(); err == nil {
+		files = append(files, filepath.Join(dir, "protected"))
+	}
+
+	var patterns []string
+	for _, file := range files {
+		p, err := ReadPatternFile(file)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		patterns = append(patterns, p...)
+	}
+	return patterns, nil
+}
@@ -0,0 +1,25 @@
+package config
+
+// Preset describes the directories a kind of project leaves build output
+// or caches in, see WithPresets.
+type Preset struct {
+	// Dirs are the directory names the preset deletes.
+	Dirs []string
+	// Marker, if set, names a file that must sit next to such a directory
+	// for it to count, for names too common to take on their own.
+	Marker string
+}
+
+// Presets are the presets WithPresets knows, by name.
+var Presets = map[string][]Preset{
+	"node": {
+		{Dirs: []string{"node_modules"}},
+		{Dirs: []string{".next", ".nuxt", ".parcel-cache", ".turbo"}, Marker: "package.json"},
+	},
+	"rust": {
+		{Dirs: []string{"target"}, Marker: "Cargo.toml"},
+	},
+	"python": {
+		{Dirs: []string{"__pycache__", ".pytest_cache", ".mypy_cache", ".ruff_cache", ".tox", ".nox"}},
+	},
+}
@@ -0,0 +1,267 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// parseTOML reads the small subset of TOML that config files need:
+// top-level "key = value" pairs with bare keys, whose values are strings,
+// decimal integers, booleans or arrays of strings, which may span several
+// lines. Strings follow TOML, escapes included. Tables, multi-line strings
+// and other value types are rejected rather than misread.
+func parseTOML(r io.Reader) (map[string]any, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(data) {
+		return nil, errors.New("not valid UTF-8")
+	}
+	p := &tomlParser{s: string(data), line: 1}
+
+	values := make(map[string]any)
+	for {
+		p.skipBlank(true)
+		if p.eof() {
+			return values, nil
+		}
+		line := p.line
+		if p.peek() == '[' {
+			return nil, fmt.Errorf("line %d: tables are not supported", line)
+		}
+
+		key := p.bareKey()
+		if key == "" {
+			return nil, fmt.Errorf("line %d: expected key = value", line)
+		}
+		p.skipBlank(false)
+		if p.peek() == '.' {
+			return nil, fmt.Errorf("line %d: dotted keys are not supported", line)
+		}
+		if p.peek() != '=' {
+			return nil, fmt.Errorf("line %d: expected key = value", line)
+		}
+		p.pos++
+		p.skipBlank(false)
+
+		value, err := p.value()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", p.line, key, err)
+		}
+		p.skipBlank(false)
+		if !p.eof() && p.peek() != '\n' && !strings.HasPrefix(p.s[p.pos:], "\r\n") {
+			return nil, fmt.Errorf("line %d: %s: unexpected %q after the value", p.line, key, p.peek())
+		}
+		if _, dup := values[key]; dup {
+			return nil, fmt.Errorf("line %d: %s is set twice", line, key)
+		}
+		values[key] = value
+	}
+}
+
+// tomlParser scans s, keeping track of the line it is on for errors.
+type tomlParser struct {
+	s    string
+	pos  int
+	line int
+}
+
+func (p *tomlParser) eof() bool  { return p.pos >= len(p.s) }
+func (p *tomlParser) peek() byte { return p.s[p.pos] }
+
+// skipBlank skips spaces, tabs and a comment up to the end of the line,
+// and with newlines, any further blank or comment lines.
+func (p *tomlParser) skipBlank(newlines bool) {
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == ' ' || c == '\t':
+			p.pos++
+		case c == '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		case newlines && c == '\n':
+			p.pos++
+			p.line++
+		case newlines && strings.HasPrefix(p.s[p.pos:], "\r\n"):
+			p.pos += 2
+			p.line++
+		default:
+			return
+		}
+	}
+}
+
+// bareKey reads a key of ASCII letters, digits, dashes and underscores.
+func (p *tomlParser) bareKey() string {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+			break
+		}
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *tomlParser) value() (any, error) {
+	if p.eof() || p.peek() == '\n' || p.peek() == '\r' || p.peek() == '#' {
+		return nil, errors.New("missing value")
+	}
+	switch p.peek() {
+	case '"', '\'':
+		return p.str()
+	case '[':
+		return p.array()
+	}
+
+	// Read the bare value up to whatever may follow it.
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(" \t\r\n#,]", rune(p.peek())) {
+		p.pos++
+	}
+	raw := p.s[start:p.pos]
+	switch raw {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	if n, ok := parseInteger(raw); ok {
+		return n, nil
+	}
+	return nil, fmt.Errorf("unsupported value %s", raw)
+}
+
+// parseInteger parses a TOML decimal integer: an optional sign, then
+// digits without leading zeros, which underscores may separate.
+func parseInteger(raw string) (int64, bool) {
+	digits := strings.TrimLeft(raw, "+-")
+	if len(raw)-len(digits) > 1 || digits == "" || len(digits) > 1 && digits[0] == '0' {
+		return 0, false
+	}
+	for i := 0; i < len(digits); i++ {
+		c := digits[i]
+		if c == '_' && (i == 0 || i == len(digits)-1 || digits[i+1] == '_') {
+			return 0, false
+		}
+		if c != '_' && (c < '0' || c > '9') {
+			return 0, false
+		}
+	}
+	n, err := strconv.ParseInt(strings.ReplaceAll(raw, "_", ""), 10, 64)
+	return n, err == nil
+}
+
+// str reads a basic "..." or literal '...' string.
+func (p *tomlParser) str() (string, error) {
+	quote := p.peek()
+	if strings.HasPrefix(p.s[p.pos:], strings.Repeat(string(quote), 3)) {
+		return "", errors.New("multi-line strings are not supported")
+	}
+	p.pos++
+
+	var b strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", errors.New("unterminated string")
+		}
+		r, size := utf8.DecodeRuneInString(p.s[p.pos:])
+		p.pos += size
+		switch {
+		case r == rune(quote):
+			return b.String(), nil
+		case r < 0x20 && r != '\t' || r == 0x7f:
+			return "", fmt.Errorf("control character %U in string", r)
+		case r == '\\' && quote == '"':
+			if err := p.escape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
+
+// escape reads the escape sequence after a backslash in a basic string.
+// Only those TOML defines are accepted.
+func (p *tomlParser) escape(b *strings.Builder) error {
+	if p.eof() {
+		return errors.New("unterminated string")
+	}
+	c := p.peek()
+	p.pos++
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case '"', '\\':
+		b.WriteByte(c)
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if len(p.s)-p.pos < n {
+			return fmt.Errorf(`invalid escape \%c`, c)
+		}
+		hex := p.s[p.pos : p.pos+n]
+		code, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || strings.ContainsAny(hex, "+-_") || !utf8.ValidRune(rune(code)) {
+			return fmt.Errorf(`invalid escape \%c%s`, c, hex)
+		}
+		p.pos += n
+		b.WriteRune(rune(code))
+	default:
+		return fmt.Errorf(`invalid escape \%c`, c)
+	}
+	return nil
+}
+
+// array reads an array of strings. It may span lines and hold comments,
+// and a trailing comma is allowed.
+func (p *tomlParser) array() ([]string, error) {
+	p.pos++
+	items := []string{}
+	for {
+		p.skipBlank(true)
+		if p.eof() {
+			return nil, errors.New("unterminated array")
+		}
+		if p.peek() == ']' {
+			p.pos++
+			return items, nil
+		}
+		if c := p.peek(); c != '"' && c != '\'' {
+			return nil, errors.New("arrays may only hold strings")
+		}
+		s, err := p.str()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, s)
+
+		p.skipBlank(true)
+		switch {
+		case p.eof():
+			return nil, errors.New("unterminated array")
+		case p.peek() == ',':
+			p.pos++
+		case p.peek() != ']':
+			return nil, errors.New("expected , between array items")
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]any
+	}{
+		{"empty", "", map[string]any{}},
+		{"comments and blank lines", "# comment\n\n  # indented\nthreads = 4 # trailing\n", map[string]any{"threads": int64(4)}},
+		{"crlf", "trash = true\r\nverbose = false\r\n", map[string]any{"trash": true, "verbose": false}},
+		{"integers", "a = -12\nb = +3\nc = 1_000\nd = 0", map[string]any{"a": int64(-12), "b": int64(3), "c": int64(1000), "d": int64(0)}},
+		{"hash inside basic string", `color = "a # b" # c`, map[string]any{"color": "a # b"}},
+		{"hash inside literal string", `color = 'a # b'`, map[string]any{"color": "a # b"}},
+		{"escapes", `format = "tab\there \"q\" \\ \u00e9 \U0001F600"`, map[string]any{"format": "tab\there \"q\" \\ é 😀"}},
+		{"literal string keeps backslashes", `format = 'C:\x41\n'`, map[string]any{"format": `C:\x41\n`}},
+		{"empty array", "excludes = []", map[string]any{"excludes": []string{}}},
+		{"one-line array", `excludes = ["*.keep", '.git']`, map[string]any{"excludes": []string{"*.keep", ".git"}}},
+		{
+			"multi-line array",
+			"excludes = [\n  \"a\", # first\n  # between\n  'b',\n]\nthreads = 2\n",
+			map[string]any{"excludes": []string{"a", "b"}, "threads": int64(2)},
+		},
+		{
+			"bracket inside a string in a multi-line array",
+			"excludes = [\n  \"x]\",\n  \"[y]\"\n]\n",
+			map[string]any{"excludes": []string{"x]", "[y]"}},
+		},
+		{"comment ending in a bracket", "excludes = [ # ]\n \"a\" ]", map[string]any{"excludes": []string{"a"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTOML(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("parseTOML: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTOML = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"table", "[section]\n", "line 1: tables are not supported"},
+		{"dotted key", "a.b = 1", "dotted keys are not supported"},
+		{"missing equals", "threads 4", "line 1: expected key = value"},
+		{"missing key", "= 4", "line 1: expected key = value"},
+		{"missing value", "threads =", "missing value"},
+		{"set twice", "a = 1\na = 2", "line 2: a is set twice"},
+		{"go hex escape", `a = "\x41"`, `invalid escape \x`},
+		{"go octal escape", `a = "\101"`, `invalid escape \1`},
+		{"go single-quote escape", `a = "\'"`, `invalid escape \'`},
+		{"short unicode escape", `a = "\u41"`, `invalid escape \u`},
+		{"surrogate escape", `a = "\uD800"`, `invalid escape \uD800`},
+		{"unterminated string", `a = "abc`, "unterminated string"},
+		{"string across lines", "a = \"abc\ndef\"", "unterminated string"},
+		{"multi-line string", `a = """abc"""`, "multi-line strings are not supported"},
+		{"control character", "a = \"a\x01b\"", "control character"},
+		{"float", "a = 1.5", "unsupported value 1.5"},
+		{"leading zero", "a = 012", "unsupported value 012"},
+		{"hex integer", "a = 0x10", "unsupported value 0x10"},
+		{"bad underscore", "a = 1__0", "unsupported value 1__0"},
+		{"bare word", "a = auto", "unsupported value auto"},
+		{"array of integers", "a = [1, 2]", "arrays may only hold strings"},
+		{"nested array", `a = [["x"]]`, "arrays may only hold strings"},
+		{"missing comma", `a = ["x" "y"]`, "expected , between array items"},
+		{"unterminated array", "a = [\"x\",\n\"y\"\n", "unterminated array"},
+		{"trailing garbage", `a = "x" "y"`, "unexpected"},
+		{"line number after multi-line array", "a = [\n\"x\",\n]\nb = 1.0", "line 4: b: unsupported value 1.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTOML(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parseTOML(%q) = %v, want an error containing %q", tt.input, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadConfigFileTypes(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"threads as string", `threads = "4"`, "threads has the wrong type"},
+		{"trash as integer", "trash = 1", "trash has the wrong type"},
+		{"color as boolean", "color = true", "color has the wrong type"},
+		{"excludes as string", `excludes = "*.keep"`, "excludes has the wrong type"},
+		{"protected paths as string", `protected_paths = "/srv"`, "protected_paths has the wrong type"},
+		{"unknown setting", "thread = 4", `unknown setting "thread"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.input), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := ReadConfigFile(path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ReadConfigFile(%q) = %v, want an error containing %q", tt.input, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package deleter
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// adaptiveInterval is how often the adaptive controller measures the
+// removals and adjusts the number of active workers.
+const adaptiveInterval = 500 * time.Millisecond
+
+// maxAdaptiveThreads is the most workers the adaptive controller grows
+// to when MaxThreads leaves it open.
+const maxAdaptiveThreads = 64
+
+// adaptive measures removals for the adaptive concurrency controller, see
+// WithAdaptiveThreads.
+type adaptive struct {
+	removals atomic.Int64
+	nanos    atomic.Int64 // spent in them
+}
+
+// timeRemove calls remove, timing it for the adaptive controller if one
+// is running.
+func (d *Deleter) timeRemove(remove func() error) error {
+	a := d.adaptive
+	if a == nil {
+		return remove()
+	}
+	start := time.Now()
+	err := remove()
+	a.removals.Add(1)
+	a.nanos.Add(int64(time.Since(start)))
+	return err
+}
+
+// startAdaptive runs the adaptive concurrency controller on sem, before
+// any worker takes a slot in it: it starts a quarter of the slots active
+// and holds the others, then every adaptiveInterval hill-climbs towards
+// the count that removes the most entries per second. It keeps adding
+// workers while that helps and turns around once it stops helping, and
+// halves them at once when removals fail or take ten times as long as
+// they did at their fastest, the marks of a saturated device. The returned
+// stop func releases the slots and records the final count in Stats.
+func (d *Deleter) startAdaptive(sem chan struct{}) (stop func()) {
+	if !d.config.AdaptiveThreads {
+		return func() {}
+	}
+	a := &adaptive{}
+	d.adaptive = a
+
+	active := cap(sem)
+	setActive := func(target int) {
+		target = min(max(target, 1), cap(sem))
+		for ; active < target; active++ {
+			d.releaseSlot(sem)
+		}
+		for ; active > target && d.holdSlot(sem, nil, false); active-- {
+		}
+	}
+	setActive(cap(sem) / 4)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		ticker := time.NewTicker(adaptiveInterval)
+		defer ticker.Stop()
+
+		var removals, nanos, errs int64
+		var lastRate, fastest float64
+		dir := 1
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			n := a.removals.Load() - removals
+			ns := a.nanos.Load() - nanos
+			e := d.stats.Snapshot().Errors - errs
+			removals, nanos, errs = removals+n, nanos+ns, errs+e
+			if n == 0 {
+				continue // only listing, say: nothing to go by
+			}
+
+			rate := float64(n) / adaptiveInterval.Seconds()
+			latency := float64(ns) / float64(n)
+			if fastest == 0 || latency < fastest {
+				fastest = latency
+			}
+			switch {
+			case e*20 > n || latency > 10*fastest:
+				setActive(active / 2)
+				dir = 1
+			case rate < lastRate*0.9:
+				dir = -dir
+				setActive(active + dir*max(active/4, 1))
+			default:
+				setActive(active + dir*max(active/4, 1))
+			}
+			lastRate = rate
+		}
+	})
+
+	return func() {
+		close(done)
+		wg.Wait()
+		d.adaptive = nil
+		d.stats.ThreadsSettled = active
+		setActive(cap(sem))
+	}
+}
+
+// holdSlot takes a slot of sem away from the workers, unless the
+// controllers between them would then hold every slot, or with all, unless
+// they hold every slot already, and reports whether it did. With a nil
+// done it does not wait for a busy worker to give one up; otherwise it
+// waits until one does or done is closed.
+func (d *Deleter) holdSlot(sem chan struct{}, done <-chan struct{}, all bool) bool {
+	limit := cap(sem) - 1
+	if all {
+		limit = cap(sem)
+	}
+	if int(d.held.Add(1)) > limit {
+		d.held.Add(-1)
+		return false
+	}
+	if done == nil {
+		select {
+		case sem <- struct{}{}:
+			return true
+		default:
+		}
+	} else {
+		select {
+		case sem <- struct{}{}:
+			return true
+		case <-done:
+		}
+	}
+	d.held.Add(-1)
+	return false
+}
+
+// releaseSlot gives a slot taken by holdSlot back to the workers.
+func (d *Deleter) releaseSlot(sem chan struct{}) {
+	<-sem
+	d.held.Add(-1)
+}
@@ -0,0 +1,121 @@
+package deleter
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/yourusername/rmrf/internal/reporter"
+)
+
+// Apply deletes exactly the entries of a previously generated plan and
+// nothing else. Every entry is checked against the plan before anything
+// is removed: one that has vanished is skipped, and one whose device,
+// inode, type or mtime differ is refused with ErrPlanDrift. Files are
+// re-checked right before removal. Entries a directory gained since the
+// plan are listed in Stats.Drift and kept along with the directory and
+// those above it, so nothing created after the plan is ever touched.
+// Entries are applied in plan order, which lists directory contents before
+// the directory itself.
+func (d *Deleter) Apply(ctx context.Context, plan []reporter.PlanEntry) (*reporter.Stats, error) {
+	progress := reporter.NewProgressReporter(len(plan), d.config.Reporter)
+	counts := d.stats.AddRoot("plan")
+	r := &run{ctx: ctx, counts: counts, progress: progress}
+
+	start := time.Now()
+	defer func() {
+		d.stats.Duration = time.Since(start)
+		if d.hasher != nil {
+			d.hashManifest()
+		}
+	}()
+
+	// kept holds the directories to leave because something below them
+	// is left.
+	kept := make(map[string]bool)
+	approved := make([]reporter.PlanEntry, 0, len(plan))
+	for _, entry := range plan {
+		switch err := d.checkPlanEntry(entry); {
+		case os.IsNotExist(err):
+			progress.Update(1)
+		case err != nil:
+			d.fail(r, fmt.Errorf("%s: %w", entry.Path, err))
+			kept[filepath.Dir(absPath(entry.Path))] = true
+			progress.Update(1)
+		default:
+			approved = append(approved, entry)
+		}
+	}
+
+	planned := newPlanSet(plan)
+	if len(approved) > 0 {
+		d.backoff = d.tune(approved[0].Path).backoff
+	}
+	for _, entry := range approved {
+		if err := ctx.Err(); err != nil {
+			progress.Complete()
+			return d.stats, fmt.Errorf("%w: %w", ErrCancelled, err)
+		}
+
+		if entry.Type == "dir" {
+			// Every drifted entry is reported, even below a directory
+			// already kept.
+			if drifted := d.keepDrifted(planned, entry.Path); drifted || kept[absPath(entry.Path)] {
+				d.stats.AddKept()
+				kept[filepath.Dir(absPath(entry.Path))] = true
+			} else {
+				d.removeDir(r, nil, entry.Path)
+			}
+		} else if info, err := os.Lstat(entry.Path); err != nil {
+			d.fail(r, err)
+		} else if err := matchPlanEntry(entry, info); err != nil {
+			d.fail(r, fmt.Errorf("%s: %w", entry.Path, err))
+		} else {
+			d.processFile(r, nil, entry.Path, fs.FileInfoToDirEntry(info))
+		}
+		progress.Update(1)
+	}
+
+	d.retryTransient(ctx.Done())
+	progress.Complete()
+	return d.stats, nil
+}
+
+// checkPlanEntry compares the path on disk with its plan entry. A plan
+// file is just as capable of naming "/" or a protected file as the command
+// line is, so every entry gets the dangerous and protected path checks,
+// and directories the rest of the usual target checks too.
+func (d *Deleter) checkPlanEntry(entry reporter.PlanEntry) error {
+	info, err := os.Lstat(entry.Path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		err = d.validatePath(entry.Path)
+	} else {
+		err = d.checkGuarded(entry.Path)
+	}
+	if err != nil {
+		return err
+	}
+	return matchPlanEntry(entry, info)
+}
+
+// matchPlanEntry compares info with entry. The mtime of a directory is
+// not compared: it changes with the entries it gains or loses, and those
+// are accounted for one by one, see keepDrifted.
+func matchPlanEntry(entry reporter.PlanEntry, info os.FileInfo) error {
+	if (entry.Type == "dir") != info.IsDir() {
+		return fmt.Errorf("%w: changed type", ErrPlanDrift)
+	}
+	if dev, ino, ok := fileID(info); ok && entry.Inode != 0 && (dev != entry.Dev || ino != entry.Inode) {
+		return fmt.Errorf("%w: replaced by another file", ErrPlanDrift)
+	}
+	if !info.IsDir() && !info.ModTime().Equal(entry.Mtime) {
+		return fmt.Errorf("%w: modified", ErrPlanDrift)
+	}
+	return nil
+}
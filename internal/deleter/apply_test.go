@@ -0,0 +1,105 @@
+package deleter
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yourusername/rmrf/internal/config"
+	"github.com/yourusername/rmrf/internal/reporter"
+)
+
+// planEntry returns the plan entry a dry run would record for path.
+func planEntry(t *testing.T, path string) reporter.PlanEntry {
+	t.Helper()
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := reporter.PlanEntry{Path: path, Type: "file", Size: info.Size(), Mtime: info.ModTime()}
+	if info.IsDir() {
+		entry.Type, entry.Size = "dir", 0
+	}
+	entry.Dev, entry.Inode, _ = fileID(info)
+	return entry
+}
+
+// hasError reports whether stats recorded an error matching target.
+func hasError(stats *reporter.Stats, target error) bool {
+	for _, err := range stats.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestApplyRefusesProtectedFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string // relative to the temp directory
+	}{
+		{"file", "keep/secret"},
+		{"glob", "keep/*"},
+		{"subtree", "keep/**"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := filepath.EvalSymlinks(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			secret, other := filepath.Join(dir, "keep", "secret"), filepath.Join(dir, "other")
+			if err := os.Mkdir(filepath.Join(dir, "keep"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			for _, path := range []string{secret, other} {
+				if err := os.WriteFile(path, nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// A hand-written plan naming the files, not their directory.
+			plan := []reporter.PlanEntry{planEntry(t, secret), planEntry(t, other)}
+
+			d := New(
+				config.WithReporter(reporter.NoopReporter{}),
+				config.WithProtectedPaths(filepath.Join(dir, tt.pattern)),
+			)
+			stats, err := d.Apply(context.Background(), plan)
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if !hasError(stats, ErrProtectedPath) {
+				t.Errorf("Apply errors = %v, want %v", stats.Errors, ErrProtectedPath)
+			}
+			if _, err := os.Lstat(secret); err != nil {
+				t.Errorf("protected file removed: %v", err)
+			}
+			if _, err := os.Lstat(other); !os.IsNotExist(err) {
+				t.Errorf("unprotected file kept: %v", err)
+			}
+		})
+	}
+}
+
+func TestApplyRefusesDangerousSymlink(t *testing.T) {
+	dir := t.TempDir()
+	link := filepath.Join(dir, "etc")
+	if err := os.Symlink("/etc", link); err != nil {
+		t.Fatal(err)
+	}
+
+	d := New(config.WithReporter(reporter.NoopReporter{}))
+	stats, err := d.Apply(context.Background(), []reporter.PlanEntry{planEntry(t, link)})
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if !hasError(stats, ErrDangerousPath) {
+		t.Errorf("Apply errors = %v, want %v", stats.Errors, ErrDangerousPath)
+	}
+	if _, err := os.Lstat(link); err != nil {
+		t.Errorf("link removed: %v", err)
+	}
+}
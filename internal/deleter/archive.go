@@ -0,0 +1,189 @@
+package deleter
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// archive is the tar stream of WithArchive. Entries are added one at a
+// time, from whichever worker is about to remove them.
+type archive struct {
+	mu   sync.Mutex
+	f    *os.File
+	comp io.WriteCloser // the compressor between tw and f, if any
+	cmd  *exec.Cmd      // an external compressor, if comp feeds one
+	tw   *tar.Writer
+}
+
+// openArchive creates the archive at path, compressed as its name says:
+// .tar.zst or .tzst with the zstd command, .tar.gz or .tgz with gzip, or
+// not at all for .tar.
+func openArchive(path string) (*archive, error) {
+	name := strings.ToLower(filepath.Base(path))
+	var compress string
+	switch {
+	case strings.HasSuffix(name, ".tar.zst"), strings.HasSuffix(name, ".tzst"):
+		compress = "zstd"
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		compress = "gzip"
+	case strings.HasSuffix(name, ".tar"):
+	default:
+		return nil, fmt.Errorf("archive %s: %w", path, ErrArchiveFormat)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	a := &archive{f: f}
+	var w io.Writer = f
+	switch compress {
+	case "zstd":
+		a.cmd = exec.Command("zstd", "-q", "-c")
+		a.cmd.Stdout = f
+		a.cmd.Stderr = os.Stderr
+		if a.comp, err = a.cmd.StdinPipe(); err == nil {
+			err = a.cmd.Start()
+		}
+		if err != nil {
+			f.Close()
+			os.Remove(path)
+			return nil, fmt.Errorf("archive %s: starting zstd: %w", path, err)
+		}
+		w = a.comp
+	case "gzip":
+		a.comp = gzip.NewWriter(f)
+		w = a.comp
+	}
+	a.tw = tar.NewWriter(w)
+	return a, nil
+}
+
+// add writes the entry at path, described by its lstat info, to the
+// archive as name. It returns once the entry is fully handed to the
+// archive, so path may then be removed.
+func (a *archive) add(name, path string, info os.FileInfo) error {
+	var link string
+	if info.Mode()&os.ModeSymlink != 0 {
+		var err error
+		if link, err = os.Readlink(path); err != nil {
+			return err
+		}
+	}
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return fmt.Errorf("archiving %s: %w", path, err)
+	}
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+
+	var body *os.File
+	if info.Mode().IsRegular() {
+		if body, err = openSame(path, info); err != nil {
+			return fmt.Errorf("archiving %s: %w", path, err)
+		}
+		defer body.Close()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("archiving %s: %w", path, err)
+	}
+	if body != nil {
+		if _, err := io.CopyN(a.tw, body, hdr.Size); err != nil {
+			return fmt.Errorf("archiving %s: %w", path, err)
+		}
+	}
+	if err := a.tw.Flush(); err != nil {
+		return fmt.Errorf("archiving %s: %w", path, err)
+	}
+	return nil
+}
+
+// close finishes the archive and syncs it to disk.
+func (a *archive) close() error {
+	err := a.tw.Close()
+	if a.comp != nil {
+		err = errors.Join(err, a.comp.Close())
+	}
+	if a.cmd != nil {
+		err = errors.Join(err, a.cmd.Wait())
+	}
+	return errors.Join(err, a.f.Sync(), a.f.Close())
+}
+
+// openSame opens the file at path for reading, failing if it is no
+// longer the one info describes.
+func openSame(path string, info os.FileInfo) (*os.File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if opened, err := f.Stat(); err != nil || !os.SameFile(info, opened) {
+		f.Close()
+		return nil, ErrDirReplaced
+	}
+	return f, nil
+}
+
+// startArchive opens the configured archive for a run and returns the
+// function that finishes it, recording an error of the run if that
+// fails. The archive may not lie inside a target. Dry runs archive
+// nothing.
+func (d *Deleter) startArchive(roots []string) (stop func(), err error) {
+	if d.config.Archive == "" || d.config.DryRun {
+		return func() {}, nil
+	}
+	abs, err := filepath.Abs(d.config.Archive)
+	if err != nil {
+		return nil, err
+	}
+	for _, root := range roots {
+		if isWithin(root, abs) {
+			return nil, fmt.Errorf("%w: %s is inside %s", ErrArchiveInside, abs, root)
+		}
+	}
+	a, err := openArchive(d.config.Archive)
+	if err != nil {
+		return nil, err
+	}
+	d.archive = a
+	return func() {
+		d.archive = nil
+		if err := a.close(); err != nil {
+			d.stats.AddError(fmt.Errorf("finishing archive %s: %w", d.config.Archive, err))
+		}
+	}, nil
+}
+
+// archiveEntry adds the entry at path in r's target to the archive, if
+// there is one, under its path relative to the target's parent, as
+// "tar -C parent target" would. info is its lstat, taken here if nil.
+func (d *Deleter) archiveEntry(r *run, path string, info os.FileInfo) error {
+	if d.archive == nil {
+		return nil
+	}
+	if info == nil {
+		var err error
+		if info, err = os.Lstat(path); err != nil {
+			return err
+		}
+	}
+	rel, err := filepath.Rel(r.root, path)
+	if err != nil {
+		return err
+	}
+	name := filepath.Join(filepath.Base(r.absRoot), rel)
+	return d.archive.add(filepath.ToSlash(name), path, info)
+}
@@ -0,0 +1,98 @@
+//go:build linux
+
+package deleter
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	atRemoveDir = 0x200    // AT_REMOVEDIR: unlinkat behaves like rmdir
+	oPath       = 0x200000 // O_PATH: a handle to the inode, not its contents
+)
+
+// openDirAt opens the subdirectory name of dir for reading without
+// resolving the path from the root again, so neither PATH_MAX nor a
+// directory renamed or swapped for a symlink further up can redirect it.
+// A symlink in place of name itself is refused.
+func openDirAt(dir *os.File, name string) (*os.File, error) {
+	path := filepath.Join(dir.Name(), name)
+	fd, err := syscall.Openat(int(dir.Fd()), name, syscall.O_RDONLY|syscall.O_DIRECTORY|syscall.O_NOFOLLOW|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+	return os.NewFile(uintptr(fd), path), nil
+}
+
+// chmodAt changes the mode of name in dir without following a symlink
+// there: fchmodat always follows one, so name is pinned with an O_PATH
+// handle first and changed through /proc. Symlinks themselves have no mode
+// to change and are left alone. Without /proc, name is opened for reading
+// instead, which fails on some unreadable entries.
+func chmodAt(dir *os.File, name string, mode os.FileMode) error {
+	err := func() error {
+		fd, err := syscall.Openat(int(dir.Fd()), name, oPath|syscall.O_NOFOLLOW|syscall.O_CLOEXEC, 0)
+		if err != nil {
+			return err
+		}
+		defer syscall.Close(fd)
+
+		var st syscall.Stat_t
+		if err := syscall.Fstat(fd, &st); err != nil {
+			return err
+		}
+		if st.Mode&syscall.S_IFMT == syscall.S_IFLNK {
+			return nil
+		}
+
+		err = syscall.Chmod("/proc/self/fd/"+strconv.Itoa(fd), uint32(mode.Perm()))
+		if err != syscall.ENOENT {
+			return err
+		}
+		rfd, err := syscall.Openat(int(dir.Fd()), name, syscall.O_RDONLY|syscall.O_NOFOLLOW|syscall.O_NONBLOCK|syscall.O_CLOEXEC, 0)
+		if err != nil {
+			return err
+		}
+		defer syscall.Close(rfd)
+		return syscall.Fchmod(rfd, uint32(mode.Perm()))
+	}()
+	if err != nil {
+		return &os.PathError{Op: "chmod", Path: filepath.Join(dir.Name(), name), Err: err}
+	}
+	return nil
+}
+
+// fileIDAt returns the device and inode numbers of name in dir, not
+// following a symlink there.
+func fileIDAt(dir *os.File, name string) (dev, ino uint64, err error) {
+	var st syscall.Stat_t
+	fd, err := syscall.Openat(int(dir.Fd()), name, oPath|syscall.O_NOFOLLOW|syscall.O_CLOEXEC, 0)
+	if err == nil {
+		err = syscall.Fstat(fd, &st)
+		syscall.Close(fd)
+	}
+	if err != nil {
+		return 0, 0, &os.PathError{Op: "lstat", Path: filepath.Join(dir.Name(), name), Err: err}
+	}
+	return uint64(st.Dev), uint64(st.Ino), nil
+}
+
+func removeAt(dir *os.File, name string, isDir bool) error {
+	p, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return err
+	}
+	flags := 0
+	if isDir {
+		flags = atRemoveDir
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_UNLINKAT, dir.Fd(), uintptr(unsafe.Pointer(p)), uintptr(flags))
+	if errno != 0 {
+		return &os.PathError{Op: "remove", Path: filepath.Join(dir.Name(), name), Err: errno}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+//go:build !linux && !windows
+
+package deleter
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Without the *at syscalls in the standard library, other platforms,
+// macOS included, resolve the full path for every call. Unlike on Linux,
+// paths past PATH_MAX fail there, and a directory further up swapped for
+// a symlink after it was opened redirects the calls below it.
+
+func openDirAt(dir *os.File, name string) (*os.File, error) {
+	return os.Open(filepath.Join(dir.Name(), name))
+}
+
+func chmodAt(dir *os.File, name string, mode os.FileMode) error {
+	path := filepath.Join(dir.Name(), name)
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return nil
+	}
+	return os.Chmod(path, mode)
+}
+
+func removeAt(dir *os.File, name string, isDir bool) error {
+	return os.Remove(filepath.Join(dir.Name(), name))
+}
+
+func fileIDAt(dir *os.File, name string) (dev, ino uint64, err error) {
+	info, err := os.Lstat(filepath.Join(dir.Name(), name))
+	if err != nil {
+		return 0, 0, err
+	}
+	dev, ino, _ = fileID(info)
+	return dev, ino, nil
+}
@@ -0,0 +1,77 @@
+//go:build windows
+
+package deleter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// Windows has no *at calls either, so every call takes the full path,
+// extended by longPath to get past MAX_PATH.
+
+func openDirAt(dir *os.File, name string) (*os.File, error) {
+	return os.Open(longPath(filepath.Join(dir.Name(), name)))
+}
+
+// chmodAt stands in for chmod, which Windows lacks: the only permission
+// that keeps a file from being deleted is FILE_ATTRIBUTE_READONLY, so that
+// is cleared, or set if mode is not writable. Reparse points are left
+// alone, like symlinks elsewhere.
+func chmodAt(dir *os.File, name string, mode os.FileMode) error {
+	path := longPath(filepath.Join(dir.Name(), name))
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+	attrs, err := syscall.GetFileAttributes(p)
+	if err != nil {
+		return &os.PathError{Op: "chmod", Path: path, Err: err}
+	}
+	if attrs&syscall.FILE_ATTRIBUTE_REPARSE_POINT != 0 {
+		return nil
+	}
+
+	want := attrs &^ syscall.FILE_ATTRIBUTE_READONLY
+	if mode&0200 == 0 {
+		want |= syscall.FILE_ATTRIBUTE_READONLY
+	}
+	if want == attrs {
+		return nil
+	}
+	if err := syscall.SetFileAttributes(p, want); err != nil {
+		return &os.PathError{Op: "chmod", Path: path, Err: err}
+	}
+	return nil
+}
+
+func removeAt(dir *os.File, name string, isDir bool) error {
+	return os.Remove(longPath(filepath.Join(dir.Name(), name)))
+}
+
+func fileIDAt(dir *os.File, name string) (dev, ino uint64, err error) {
+	if _, err := os.Lstat(longPath(filepath.Join(dir.Name(), name))); err != nil {
+		return 0, 0, err
+	}
+	return 0, 0, nil
+}
+
+// longPath turns path into an extended-length \\?\ path once it is long
+// enough to hit MAX_PATH. The os package does so itself only for absolute
+// paths, and a deep tree reached from a relative target has none.
+func longPath(path string) string {
+	const maxDirPath = 248 // MAX_PATH less room for an 8.3 file name
+	if len(path) < maxDirPath || strings.HasPrefix(path, `\\?\`) {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if strings.HasPrefix(abs, `\\`) {
+		return `\\?\UNC\` + abs[2:]
+	}
+	return `\\?\` + abs
+}
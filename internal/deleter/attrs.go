@@ -0,0 +1,58 @@
+package deleter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// checkAttrs is called when removing path, in the open directory parent
+// if that is given, failed with err. If an immutable or append-only
+// attribute on the entry or its directory is why, it either clears them
+// and calls remove again, with WithClearAttrs, or returns ErrAttrProtected.
+// Any other error is returned as is. The attributes of special files are
+// not looked at, since that means opening them.
+func (d *Deleter) checkAttrs(parent *os.File, path string, special bool, remove func() error, err error) error {
+	if !errors.Is(err, syscall.EPERM) {
+		return err
+	}
+	if parent == nil {
+		dir, openErr := os.Open(filepath.Dir(path))
+		if openErr != nil {
+			return err
+		}
+		defer dir.Close()
+		parent = dir
+	}
+	name := filepath.Base(path)
+
+	var protected []*os.File
+	if !special {
+		if entry, openErr := openAttrAt(parent, name); openErr == nil {
+			defer entry.Close()
+			if protectedByAttrs(entry) {
+				protected = append(protected, entry)
+			}
+		}
+	}
+	if protectedByAttrs(parent) {
+		protected = append(protected, parent)
+	}
+	if len(protected) == 0 {
+		return err
+	}
+
+	if !d.config.ClearAttrs {
+		d.stats.AddAttrProtected()
+		return fmt.Errorf("%w: %s", ErrAttrProtected, path)
+	}
+	for _, f := range protected {
+		if err := d.fs.ClearAttrs(f); err != nil {
+			d.stats.AddAttrProtected()
+			return err
+		}
+	}
+	return remove()
+}
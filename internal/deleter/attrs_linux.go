@@ -0,0 +1,57 @@
+//go:build linux
+
+package deleter
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	fsImmutableFl = 0x10 // FS_IMMUTABLE_FL, chattr +i
+	fsAppendFl    = 0x20 // FS_APPEND_FL, chattr +a
+
+	// FS_IOC_GETFLAGS and FS_IOC_SETFLAGS are declared as taking a long,
+	// which puts its size into their numbers, though they pass an int.
+	fsIocGetFlags = 0x80006601 | unsafe.Sizeof(uintptr(0))<<16
+	fsIocSetFlags = 0x40006602 | unsafe.Sizeof(uintptr(0))<<16
+)
+
+// openAttrAt opens name in dir just to read or change its attributes.
+func openAttrAt(dir *os.File, name string) (*os.File, error) {
+	fd, err := syscall.Openat(int(dir.Fd()), name, syscall.O_RDONLY|syscall.O_NOFOLLOW|syscall.O_NONBLOCK|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	return os.NewFile(uintptr(fd), name), nil
+}
+
+// protectedByAttrs reports whether f has the immutable or append-only
+// attribute set. Filesystems without attributes have neither.
+func protectedByAttrs(f *os.File) bool {
+	flags, err := attrFlags(f)
+	return err == nil && flags&(fsImmutableFl|fsAppendFl) != 0
+}
+
+// clearAttrs clears the immutable and append-only attributes of f, which
+// takes CAP_LINUX_IMMUTABLE.
+func clearAttrs(f *os.File) error {
+	flags, err := attrFlags(f)
+	if err != nil {
+		return err
+	}
+	flags &^= fsImmutableFl | fsAppendFl
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), fsIocSetFlags, uintptr(unsafe.Pointer(&flags))); errno != 0 {
+		return &os.PathError{Op: "clear attributes", Path: f.Name(), Err: errno}
+	}
+	return nil
+}
+
+func attrFlags(f *os.File) (int32, error) {
+	var flags int32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), fsIocGetFlags, uintptr(unsafe.Pointer(&flags))); errno != 0 {
+		return 0, errno
+	}
+	return flags, nil
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+
+package deleter
+
+import (
+	"errors"
+	"os"
+)
+
+// Immutable and append-only attributes are only looked at on Linux.
+
+func openAttrAt(dir *os.File, name string) (*os.File, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func protectedByAttrs(f *os.File) bool {
+	return false
+}
+
+func clearAttrs(f *os.File) error {
+	return errors.ErrUnsupported
+}
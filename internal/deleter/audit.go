@@ -0,0 +1,76 @@
+package deleter
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// auditLog is the append-only record of WithAuditLog: one line per
+// removed entry, written as it is removed. Each line is written under an
+// exclusive lock on the file, so runs sharing a log never interleave
+// theirs.
+type auditLog struct {
+	mu       sync.Mutex
+	f        *os.File
+	uid, pid int
+}
+
+// openAuditLog opens the audit log at path for appending, creating it
+// readable by its owner only.
+func openAuditLog(path string) (*auditLog, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("opening audit log: %w", err)
+	}
+	return &auditLog{f: f, uid: os.Getuid(), pid: os.Getpid()}, nil
+}
+
+// write appends the line for one removal, op being the event type.
+func (a *auditLog) write(op, path string) error {
+	line := fmt.Sprintf("%s uid=%d pid=%d op=%s path=%s\n",
+		time.Now().UTC().Format(time.RFC3339Nano), a.uid, a.pid, op, strconv.Quote(path))
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := lockFile(a.f); err != nil {
+		return err
+	}
+	defer unlockFile(a.f)
+	_, err := a.f.WriteString(line)
+	return err
+}
+
+func (a *auditLog) Close() error {
+	return a.f.Close()
+}
+
+// startAudit opens the configured audit log for a run and returns the
+// function that closes it. Dry runs remove nothing and log nothing.
+func (d *Deleter) startAudit() (stop func(), err error) {
+	if d.config.AuditLog == "" || d.config.DryRun {
+		return func() {}, nil
+	}
+	log, err := openAuditLog(d.config.AuditLog)
+	if err != nil {
+		return nil, err
+	}
+	d.audit = log
+	return func() {
+		d.audit = nil
+		log.Close()
+	}, nil
+}
+
+// auditRemoved logs a removal. A line that can't be written is an error
+// of the run, not a reason to stop it.
+func (d *Deleter) auditRemoved(op, path string) {
+	if d.audit == nil {
+		return
+	}
+	if err := d.audit.write(op, path); err != nil {
+		d.stats.AddError(fmt.Errorf("writing audit log: %w", err))
+	}
+}
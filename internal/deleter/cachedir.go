@@ -0,0 +1,31 @@
+package deleter
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// cacheTagSignature is how a valid CACHEDIR.TAG file starts.
+const cacheTagSignature = "Signature: 8a477f597d28d172789f06886806bc55"
+
+// hasCacheTag reports whether dir holds a valid CACHEDIR.TAG file. Only the
+// signature is read, and a tag that is not a regular file is ignored, so
+// a FIFO named like one can't hang the run.
+func hasCacheTag(dir string) bool {
+	tag := filepath.Join(dir, "CACHEDIR.TAG")
+	if info, err := os.Lstat(tag); err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	f, err := os.Open(tag)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	buf := make([]byte, len(cacheTagSignature))
+	if _, err := io.ReadFull(f, buf); err != nil {
+		return false
+	}
+	return bytes.Equal(buf, []byte(cacheTagSignature))
+}
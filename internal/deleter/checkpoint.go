@@ -0,0 +1,140 @@
+package deleter
+
+import (
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/yourusername/rmrf/internal/reporter"
+)
+
+// checkpointInterval is how often the checkpoint file is rewritten during
+// a run.
+const checkpointInterval = 5 * time.Second
+
+// checkpoint tracks the traversal state a run saves, see WithCheckpoint.
+type checkpoint struct {
+	path string
+	args []string
+	dir  string
+
+	mu        sync.Mutex
+	roots     []string
+	completed map[string]bool
+	pending   map[string]bool
+}
+
+// startCheckpoint begins saving the traversal of roots to the checkpoint
+// file every checkpointInterval, with WithCheckpoint. The returned stop
+// func saves it a last time, or removes it if the run is complete: after
+// that there is nothing left to resume.
+func (d *Deleter) startCheckpoint(roots []string) (stop func(complete bool), err error) {
+	if d.config.Checkpoint == "" || d.config.DryRun {
+		return func(bool) {}, nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	c := &checkpoint{
+		path:      d.config.Checkpoint,
+		args:      d.config.CheckpointArgs,
+		dir:       wd,
+		roots:     roots,
+		completed: make(map[string]bool),
+		pending:   make(map[string]bool),
+	}
+	for _, path := range d.config.Resume {
+		c.completed[path] = true
+	}
+	if err := c.save(); err != nil {
+		return nil, err
+	}
+	d.checkpoint = c
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		ticker := time.NewTicker(checkpointInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := c.save(); err != nil {
+					d.stats.AddWarning("saving checkpoint: " + err.Error())
+				}
+			}
+		}
+	})
+	return func(complete bool) {
+		close(done)
+		wg.Wait()
+		d.checkpoint = nil
+		if complete {
+			os.Remove(c.path)
+		} else if err := c.save(); err != nil {
+			d.stats.AddWarning("saving checkpoint: " + err.Error())
+		}
+	}, nil
+}
+
+// save writes the checkpoint file. Completed subtrees inside other
+// completed ones are left out: resuming skips them anyway.
+func (c *checkpoint) save() error {
+	c.mu.Lock()
+	cp := reporter.Checkpoint{Args: c.args, Dir: c.dir, Targets: c.roots}
+	for path := range c.completed {
+		cp.Completed = append(cp.Completed, path)
+	}
+	for path := range c.pending {
+		cp.Pending = append(cp.Pending, path)
+	}
+	c.mu.Unlock()
+
+	slices.Sort(cp.Completed)
+	outer := cp.Completed[:0]
+	for _, path := range cp.Completed {
+		if len(outer) == 0 || !isWithin(outer[len(outer)-1], path) {
+			outer = append(outer, path)
+		}
+	}
+	cp.Completed = outer
+	slices.Sort(cp.Pending)
+	return cp.WriteFile(c.path)
+}
+
+// resumed reports whether t's directory was completed by the run the
+// checkpoint being resumed was saved by, and if so marks it kept; it is
+// not listed again. Otherwise t is recorded as pending.
+func (d *Deleter) resumed(t *dirTask) bool {
+	c := d.checkpoint
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.completed[t.path] {
+		t.kept.Store(true)
+		return true
+	}
+	c.pending[t.path] = true
+	return false
+}
+
+// checkpointDone records that t's directory is finished with, and, if
+// everything left in it is kept, that its subtree is complete.
+func (d *Deleter) checkpointDone(t *dirTask, kept bool) {
+	c := d.checkpoint
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, t.path)
+	if kept {
+		c.completed[t.path] = true
+	}
+}
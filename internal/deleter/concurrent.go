@@ -1,79 +1,551 @@
 package deleter
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/yourusername/rmrf/internal/config"
+	"github.com/yourusername/rmrf/internal/reporter"
 )
 
-func (d *Deleter) deleteRecursive(path string, wg *sync.WaitGroup, sem chan struct{}, progress 
-*reporter.ProgressReporter) {
-	defer wg.Done()
+// run holds the state shared by every task working on one target.
+type run struct {
+	ctx      context.Context
+	cancel   context.CancelFunc // stops the whole run; nil if it cannot be
+	root     string
+	counts   *reporter.RootStats
+	pool     *pool
+	progress *reporter.ProgressReporter
+	started  time.Time // what filters measure ages from
+	err      error     // set by the barrier, see deleteRoot
+
+	dev      uint64 // device of root, for WithOneFileSystem
+	devKnown bool
+
+	// With SymlinkFollow, realRoot is root with symlinks resolved, and
+	// visited holds the fileKey of every directory entered.
+	realRoot string
+	visited  sync.Map
+
+	absRoot string // root made absolute, see protectedEntry
+	shred   bool   // overwrite files before removing them, see shredTarget
+}
+
+// readDirBatch is how many directory entries are read and handled at a
+// time.
+const readDirBatch = 1024
+
+// dirTask tracks a directory whose contents are being deleted. pending
+// counts its subdirectories still in progress plus one for its own
+// listing; whoever brings it to zero finishes the directory, so a
+// directory is removed only after everything below it, without any
+// goroutine waiting on its children.
+//
+// The directory stays open until then, and its entries, subdirectories
+// included, are opened and removed relative to that handle rather than
+// by path.
+type dirTask struct {
+	path     string
+	parent   *dirTask
+	dir      *os.File
+	pending  atomic.Int32
+	kept     atomic.Bool   // something inside is deliberately left in place
+	deferred atomic.Bool   // something inside is left to be removed at reboot
+	failed   bool          // the directory could not be listed
+	follow   bool          // reached through a symlink, see followLink
+	hops     int           // symlinks followed to get here
+	depth    int           // levels below the target
+	ignores  []*ignoreFile // in effect here, see loadIgnores
+	subvol   bool          // a nested btrfs subvolume, see leaveSubvolume
+
+	// dev and ino identify the directory as it was listed in its parent,
+	// or as the target was stat'ed; zero if unknown.
+	dev, ino uint64
 
-	if err := d.makeDeletable(path); err != nil {
-		d.stats.AddError(err)
+	// finish, if set, replaces the default of removing the emptied
+	// directory; the target itself uses it to run the barrier.
+	finish func(t *dirTask)
+}
+
+func newDirTask(path string, parent *dirTask) *dirTask {
+	t := &dirTask{path: path, parent: parent}
+	if parent != nil {
+		t.depth = parent.depth + 1
+	}
+	t.pending.Store(1)
+	return t
+}
+
+// clearDir deletes the files in t's directory and queues its
+// subdirectories on the pool, each as a dirTask of its own, see queueDir. Entries that
+// are filtered, declined or otherwise left in place mark t as kept.
+func (d *Deleter) clearDir(r *run, t *dirTask) {
+	defer d.release(r, t)
+
+	if r.ctx.Err() != nil || d.resumed(t) {
 		return
 	}
 
-	entries, err := os.ReadDir(path)
+	dir, err := d.openDir(t)
 	if err != nil {
-		d.stats.AddError(err)
+		d.fail(r, err)
+		t.failed = true
+		return
+	}
+	t.dir = dir
+	d.watchDir(t.path)
+	if !t.follow && !d.enter(r, t) {
+		t.kept.Store(true)
+		return
+	}
+	if t.depth < d.config.MinDepth {
+		t.kept.Store(true)
+	}
+	t.ignores = d.loadIgnores(r, t)
+	if d.config.SkipCacheDirs && hasCacheTag(t.path) {
+		d.emitSkipped(t.path, "cache directory")
+		t.kept.Store(true)
+		return
+	}
+	if err := d.archiveEntry(r, t.path, nil); err != nil {
+		d.failEntry(r, t.path, nil, err)
+		t.kept.Store(true)
 		return
 	}
 
-	progress.Total += len(entries)
-	var subWg sync.WaitGroup
+	// Read in batches so a directory with millions of entries never has
+	// to be listed, or sorted, in memory all at once.
+	skipRest := false
+	for r.ctx.Err() == nil {
+		entries, err := dir.ReadDir(readDirBatch)
+		r.progress.AddTotal(len(entries))
+		d.stats.AddEntries(len(entries))
 
-	for _, entry := range entries {
-		fullPath := filepath.Join(path, entry.Name())
+		for _, entry := range entries {
+			if !d.clearEntry(r, t, entry, &skipRest) {
+				return
+			}
+		}
 
-		if entry.Type()&os.ModeSymlink != 0 && d.config.SkipSymlinks {
-			d.stats.AddError(fmt.Errorf("skipped symlink: %s", fullPath))
-			continue
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			d.fail(r, err)
+			t.failed = true
+			return
 		}
+	}
+}
 
-		if entry.IsDir() {
-			select {
-			case sem <- struct{}{}:
-				subWg.Add(1)
-				go func(p string) {
-					defer func() { <-sem }()
-					d.deleteRecursive(p, &subWg, sem, progress)
-				}(fullPath)
-			default:
-				d.deleteRecursive(fullPath, &subWg, sem, progress)
-			}
-		} else {
-			d.processFile(fullPath)
-			progress.Update(1)
+// queueDir has child's directory cleared on the pool, or right away on
+// this goroutine when the queue is full, so a directory with millions of
+// subdirectories can't fill memory with tasks waiting to run.
+func (d *Deleter) queueDir(r *run, child *dirTask) {
+	if !r.pool.trySubmit(func() { d.clearDir(r, child) }) {
+		d.clearDir(r, child)
+	}
+}
+
+// clearEntry deletes or queues one entry of t's directory. It returns
+// false once the run is stopped and the rest of the directory must be
+// left alone. skipRest carries an interactive "d" answer from one entry to
+// the next.
+func (d *Deleter) clearEntry(r *run, t *dirTask, entry os.DirEntry, skipRest *bool) bool {
+	if r.ctx.Err() != nil {
+		return false
+	}
+
+	fullPath := filepath.Join(t.path, entry.Name())
+
+	if d.keepEntry(r, fullPath, t.depth+1, entry) {
+		d.stats.AddKept()
+		d.emitSkipped(fullPath, "filtered")
+		t.kept.Store(true)
+		r.progress.Update(1)
+		return true
+	}
+	if ignored(t, fullPath, entry) {
+		d.stats.AddKept()
+		d.emitSkipped(fullPath, "ignored")
+		t.kept.Store(true)
+		r.progress.Update(1)
+		return true
+	}
+	if d.protectedEntry(r, fullPath) {
+		d.stats.AddKept()
+		d.emitSkipped(fullPath, "protected")
+		t.kept.Store(true)
+		r.progress.Update(1)
+		return true
+	}
+	if d.unplanned(fullPath) {
+		d.stats.AddKept()
+		t.kept.Store(true)
+		r.progress.Update(1)
+		return true
+	}
+
+	link := isReparsePoint(entry)
+	if link && d.config.SymlinkPolicy == config.SymlinkSkip {
+		d.stats.AddSymlinkSkipped()
+		d.emitSkipped(fullPath, "symlink")
+		d.fail(r, fmt.Errorf("skipped symlink: %s", fullPath))
+		t.kept.Store(true)
+		r.progress.Update(1)
+		return true
+	}
+
+	if d.leaveSpecial(r, fullPath, entry) {
+		t.kept.Store(true)
+		r.progress.Update(1)
+		return true
+	}
+
+	if d.onOtherDevice(r, fullPath, entry) {
+		t.kept.Store(true)
+		r.progress.Update(1)
+		return true
+	}
+
+	ok := false
+	if !*skipRest {
+		ok, *skipRest = d.approve(r, fullPath, entry.IsDir())
+	}
+	if !ok {
+		if r.ctx.Err() != nil {
+			return false
+		}
+		d.stats.AddSkipped(fullPath)
+		d.emitSkipped(fullPath, "declined")
+		t.kept.Store(true)
+		r.progress.Update(1)
+		return true
+	}
+
+	switch {
+	case entry.IsDir() && !link:
+		dev, ino, err := fileIDAt(t.dir, entry.Name())
+		if err != nil {
+			d.fail(r, err)
+			r.progress.Update(1)
+			return true
+		}
+		subvol, keep := d.leaveSubvolume(t, fullPath, dev, ino)
+		if keep {
+			t.kept.Store(true)
+			r.progress.Update(1)
+			return true
+		}
+		child := newDirTask(fullPath, t)
+		child.dev, child.ino = dev, ino
+		child.hops = t.hops
+		child.subvol = subvol
+		t.pending.Add(1)
+		d.queueDir(r, child)
+	case link && d.followLink(r, t, fullPath, entry):
+	default:
+		if d.processFile(r, t.dir, fullPath, entry) {
+			t.deferred.Store(true)
+		}
+		r.progress.Update(1)
+	}
+	return true
+}
+
+// openDir opens t's directory, below the target relative to the parent's
+// handle, and makes it writable if it is not, so its entries can be
+// removed. An unreadable directory is made readable and opened again.
+// How much of that is done is up to the ChmodPolicy.
+//
+// Whoever can write to the parent could have replaced the directory since
+// it was listed, by a symlink to somewhere else, say, so what was opened is
+// checked to be the same directory. If it is not, ErrDirReplaced is
+// returned and nothing below it is touched.
+func (d *Deleter) openDir(t *dirTask) (*os.File, error) {
+	if d.config.ChmodPolicy == config.ChmodAlways && !d.config.DryRun {
+		if err := d.chmodDir(t); err != nil {
+			return nil, err
+		}
+	}
+
+	dir, err := d.open(t)
+	if d.mayChmod(err) {
+		if err = d.chmodDir(t); err == nil {
+			dir, err = d.open(t)
+		}
+	}
+	if t.parent != nil && (errors.Is(err, syscall.ELOOP) || errors.Is(err, syscall.ENOTDIR)) {
+		err = fmt.Errorf("%w: %s is no longer a directory", ErrDirReplaced, t.path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := dir.Stat()
+	if err != nil {
+		dir.Close()
+		return nil, err
+	}
+	if dev, ino, ok := fileID(info); ok && t.ino != 0 && (dev != t.dev || ino != t.ino) {
+		dir.Close()
+		return nil, fmt.Errorf("%w: %s", ErrDirReplaced, t.path)
+	}
+	if info.Mode().Perm()&0300 != 0300 && d.config.ChmodPolicy != config.ChmodNever && !d.config.DryRun {
+		if err := d.chmodDir(t); err != nil {
+			dir.Close()
+			return nil, err
 		}
 	}
+	return dir, nil
+}
+
+func (d *Deleter) open(t *dirTask) (*os.File, error) {
+	switch {
+	case d.config.Root != nil:
+		return openInRoot(d.config.Root, t.path)
+	case t.parent == nil, t.follow:
+		return os.Open(t.path)
+	}
+	return openDirAt(t.parent.dir, filepath.Base(t.path))
+}
+
+func (d *Deleter) chmodDir(t *dirTask) error {
+	if t.parent == nil || t.follow {
+		return d.makeDeletable(t.path)
+	}
+	return d.fs.ChmodAt(t.parent.dir, filepath.Base(t.path), 0700)
+}
+
+// deferRemove schedules path to be removed at the next reboot, see
+// WithDeferLocked, and reports whether that worked.
+func (d *Deleter) deferRemove(r *run, path string) bool {
+	if err := d.fs.DeferRemove(path); err != nil {
+		d.fail(r, err)
+		return false
+	}
+	d.stats.AddPending(path)
+	return true
+}
+
+// mayChmod reports whether err is a permission error that changing the
+// mode might fix, and doing so is allowed.
+func (d *Deleter) mayChmod(err error) bool {
+	return d.config.ChmodPolicy != config.ChmodNever && !d.config.DryRun && errors.Is(err, fs.ErrPermission)
+}
 
-	subWg.Wait()
+// release drops one pending reference to t, finishing t and then any
+// ancestors whose last reference that was. Tasks still run after the run
+// is cancelled, returning at once, so every directory is finished and
+// closed even though nothing more is removed.
+func (d *Deleter) release(r *run, t *dirTask) {
+	for t != nil && t.pending.Add(-1) == 0 {
+		d.finishDir(r, t)
+		t = t.parent
+	}
+}
 
-	if !d.config.DryRun {
-		if err := os.Remove(path); err != nil {
-			d.stats.AddError(err)
-		} else {
-			d.stats.DirsDeleted++
+// finishDir removes t's directory once everything inside it is gone, or
+// keeps it, and tells its parent to do the same, if anything was kept.
+func (d *Deleter) finishDir(r *run, t *dirTask) {
+	if t.dir != nil {
+		t.dir.Close()
+	}
+	if t.parent != nil {
+		defer r.progress.Update(1)
+	}
+	if r.ctx.Err() == nil && !t.failed {
+		d.checkpointDone(t, t.kept.Load())
+	}
+	switch {
+	case r.ctx.Err() != nil, t.failed:
+	case t.kept.Load():
+		d.stats.AddKept()
+		if t.parent != nil {
+			t.parent.kept.Store(true)
 		}
+	case t.deferred.Load():
+		if d.deferRemove(r, t.path) && t.parent != nil {
+			t.parent.deferred.Store(true)
+		}
+	case t.finish != nil:
+		t.finish(t)
+	case t.subvol:
+		d.removeSubvolume(r, t.parent.dir, t.path)
+	default:
+		d.removeDir(r, t.parent.dir, t.path)
+	}
+}
+
+// removeDir removes the empty directory at path. If parent, the open
+// directory containing it, is given, the removal is relative to it.
+func (d *Deleter) removeDir(r *run, parent *os.File, path string) {
+	remove := func() error { return d.fs.Remove(path) }
+	if parent != nil {
+		remove = func() error { return d.fs.RemoveAt(parent, filepath.Base(path), true) }
 	}
+	d.removeEmptyDir(r, parent, path, remove)
 }
 
-func (d *Deleter) processFile(path string) {
+// removeEmptyDir removes the empty directory at path with remove, and
+// accounts for it. A dry run only accounts for it.
+func (d *Deleter) removeEmptyDir(r *run, parent *os.File, path string, remove func() error) {
 	if d.config.DryRun {
-		d.stats.FilesDeleted++
-		return
+		remove = func() error { return nil }
+	}
+	var info os.FileInfo
+	if d.recording() {
+		info, _ = os.Lstat(path)
 	}
+	d.throttle(r.ctx, 0)
+	err := heldOpen(path, true, d.checkAttrs(parent, path, false, remove, d.timeRemove(remove)))
+	switch {
+	case err != nil && d.retryLater(retryItem{r: r, path: path, info: info, dir: true, err: err}):
+	case err != nil && d.appeared(path):
+	case err != nil:
+		d.failEntry(r, path, info, err)
+	default:
+		d.dirRemoved(r, path, info)
+	}
+}
 
-	if err := os.Chmod(path, 0600); err != nil {
-		d.stats.AddError(err)
+// processFile removes a single non-directory entry. Its lstat is taken
+// first to account for the bytes freed. If parent, the open directory
+// containing it, is given, the calls are relative to it. Unless the
+// ChmodPolicy says otherwise, the entry is made writable and removal tried
+// again only if removal is refused, which is what read-only files need on
+// Windows; special files are never chmod'ed. A dry run only accounts for
+// the entry. It reports whether the entry, being locked, was left to be
+// removed at reboot.
+func (d *Deleter) processFile(r *run, parent *os.File, path string, entry os.DirEntry) (deferred bool) {
+	info, _ := entry.Info()
+
+	chmod := func() error { return d.fs.Chmod(path, 0600) }
+	remove := func() error { return d.fs.Remove(path) }
+	if parent != nil {
+		name := filepath.Base(path)
+		chmod = func() error { return d.fs.ChmodAt(parent, name, 0600) }
+		remove = func() error { return d.fs.RemoveAt(parent, name, false) }
+	}
+	if d.config.DryRun {
+		remove = func() error { return nil }
+	}
+
+	special := isSpecial(entry)
+	if d.config.ChmodPolicy == config.ChmodAlways && !special && !d.config.DryRun {
+		if err := chmod(); err != nil {
+			d.fail(r, err)
+			return false
+		}
+	}
+
+	sum, err := d.erasureHash(path, entry, info)
+	if err == nil {
+		err = d.archiveEntry(r, path, info)
+	}
+	if err != nil {
+		d.failEntry(r, path, info, err)
+		return false
+	}
+	if err := d.shred(r, path, entry, info); err != nil {
+		if d.mayChmod(err) {
+			if err = chmod(); err == nil {
+				err = d.shred(r, path, entry, info)
+			}
+		}
+		if err != nil {
+			d.fail(r, err)
+			return false
+		}
+	}
+
+	var size int64
+	if info != nil && info.Mode().IsRegular() {
+		size = info.Size()
+	}
+	d.throttle(r.ctx, size)
+	err = d.timeRemove(remove)
+	if d.mayChmod(err) && !special {
+		if err = chmod(); err == nil {
+			err = remove()
+		}
+	}
+	err = heldOpen(path, false, d.checkAttrs(parent, path, special, remove, err))
+	if err != nil && d.config.DeferLocked && isLocked(err) {
+		return d.deferRemove(r, path)
+	}
+	link := isReparsePoint(entry)
+	switch {
+	case err != nil && d.retryLater(retryItem{r: r, path: path, info: info, sum: sum, link: link, err: err}):
+	case err != nil:
+		d.failEntry(r, path, info, err)
+	default:
+		d.fileRemoved(r, path, info, sum, link)
+	}
+	return false
+}
+
+// fail records err for the run as a whole and for the target it hit.
+func (d *Deleter) fail(r *run, err error) {
+	d.stats.AddError(err)
+	r.counts.IncErrors()
+	d.manifestError(err)
+	if path, ok := errorPath(err); ok && d.config.Failed != nil {
+		d.config.Failed(path)
+	}
+}
+
+// failEntry is fail for an error removing the entry at path, whose lstat
+// info is, if taken.
+func (d *Deleter) failEntry(r *run, path string, info os.FileInfo, err error) {
+	d.stats.AddError(err)
+	r.counts.IncErrors()
+	d.recordManifest(path, info, reporter.StatusError, err.Error())
+	if d.config.Failed != nil {
+		d.config.Failed(path)
+	}
+}
+
+// recording reports whether deleted entries must be stat'ed beforehand
+// for the result hash or the plan.
+func (d *Deleter) recording() bool {
+	return d.hasher != nil || d.planning() || d.config.Erasure != nil || d.config.Manifest != nil
+}
+
+// hashManifest stores the result hash of what has been deleted so far in
+// the stats, see WithResultHash.
+func (d *Deleter) hashManifest() {
+	sum, err := d.hasher.Sum()
+	if err != nil {
+		d.stats.AddWarning(fmt.Sprintf("computing the result hash: %v", err))
 		return
 	}
+	d.stats.ManifestHash = sum
+}
 
-	if err := os.Remove(path); err != nil {
-		d.stats.AddError(err)
-	} else {
-		d.stats.FilesDeleted++
+// recordDeleted adds a successfully deleted entry to the result hash, the
+// plan, the erasure record and the manifest. info is the entry's lstat from before
+// removal, if taken, and sum its erasureHash.
+func (d *Deleter) recordDeleted(path string, info os.FileInfo, sum string) {
+	if d.hasher != nil {
+		var size int64
+		if info != nil && !info.IsDir() {
+			size = info.Size()
+		}
+		d.hasher.Add(path, size)
+	}
+	d.recordPlan(path, info)
+	d.recordErasure(path, info, sum)
+	if info != nil {
+		d.recordManifest(path, info, reporter.StatusDeleted, "")
 	}
 }
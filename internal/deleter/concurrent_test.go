@@ -0,0 +1,130 @@
+package deleter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/yourusername/rmrf/internal/config"
+	"github.com/yourusername/rmrf/internal/reporter"
+)
+
+// makeWideTree creates files spread over nested directories under root,
+// itself included, and returns how many files and directories it made.
+func makeWideTree(t *testing.T, root string, fanout, depth, filesPerDir int) (files, dirs int64) {
+	t.Helper()
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	dirs++
+	for i := range filesPerDir {
+		if err := os.WriteFile(filepath.Join(root, fmt.Sprintf("f%d", i)), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files++
+	}
+	if depth == 0 {
+		return files, dirs
+	}
+	for i := range fanout {
+		f, d := makeWideTree(t, filepath.Join(root, fmt.Sprintf("d%d", i)), fanout, depth-1, filesPerDir)
+		files += f
+		dirs += d
+	}
+	return files, dirs
+}
+
+// TestConcurrentDeleteCounts deletes a tree of a few thousand entries with
+// many workers, best run with -race, and checks that every file and
+// directory was counted exactly once and none is left.
+func TestConcurrentDeleteCounts(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []config.Option
+	}{
+		{"standard", nil},
+		{"one worker", []config.Option{config.WithMaxThreads(1)}},
+		{"many workers", []config.Option{config.WithMaxThreads(64)}},
+		{"uring", []config.Option{config.WithEngine(config.EngineURing)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := filepath.Join(t.TempDir(), "tree")
+			// 1+4+16+64+256 = 341 directories of 10 files each.
+			files, dirs := makeWideTree(t, root, 4, 4, 10)
+
+			opts := append([]config.Option{config.WithReporter(reporter.NoopReporter{})}, tt.opts...)
+			stats, err := New(opts...).Delete(root)
+			if err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if got, want := stats.FilesDeleted+stats.DirsDeleted, files+dirs; got != want {
+				t.Errorf("deleted %d files and %d directories, %d in all; created %d files and %d directories, %d in all",
+					stats.FilesDeleted, stats.DirsDeleted, got, files, dirs, want)
+			}
+			if _, err := os.Lstat(root); !os.IsNotExist(err) {
+				t.Errorf("%s still exists: %v", root, err)
+			}
+		})
+	}
+}
+
+// TestFullQueue deletes a wide tree with the pool's queue bounded to a
+// couple of tasks, so most directories are cleared inline by whichever
+// worker listed them, and checks nothing is missed or counted twice.
+func TestFullQueue(t *testing.T) {
+	defer func(n int) { maxQueued = n }(maxQueued)
+	maxQueued = 2
+
+	root := filepath.Join(t.TempDir(), "tree")
+	// 1+8+64 = 73 directories of 3 files each.
+	files, dirs := makeWideTree(t, root, 8, 2, 3)
+	stats, err := New(config.WithReporter(reporter.NoopReporter{}), config.WithMaxThreads(4)).Delete(root)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if stats.FilesDeleted != files || stats.DirsDeleted != dirs {
+		t.Errorf("deleted %d files and %d directories, want %d and %d",
+			stats.FilesDeleted, stats.DirsDeleted, files, dirs)
+	}
+	if _, err := os.Lstat(root); !os.IsNotExist(err) {
+		t.Errorf("%s still exists: %v", root, err)
+	}
+}
+
+func TestTrySubmit(t *testing.T) {
+	defer func(n int) { maxQueued = n }(maxQueued)
+	maxQueued = 2
+
+	// With the only semaphore slot held, the worker takes the first task
+	// and blocks, and the queue fills up behind it.
+	sem := make(chan struct{}, 1)
+	sem <- struct{}{}
+	p := newPool(1, sem)
+	ran := 0
+	queued := []bool{p.trySubmit(func() { ran++ })}
+	for {
+		p.mu.Lock()
+		taken := len(p.queue) == 0
+		p.mu.Unlock()
+		if taken {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for range 3 {
+		queued = append(queued, p.trySubmit(func() { ran++ }))
+	}
+	<-sem
+	p.wait()
+
+	if want := []bool{true, true, true, false}; !slices.Equal(queued, want) {
+		t.Errorf("trySubmit = %v, want %v", queued, want)
+	}
+	if ran != 3 {
+		t.Errorf("ran %d tasks, want 3", ran)
+	}
+}
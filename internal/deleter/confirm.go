@@ -0,0 +1,176 @@
+package deleter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/rmrf/internal/reporter"
+)
+
+// riskyDepth is how close to the filesystem root a target has to be, in
+// path components, to need its name typed, see WithConfirmByName.
+const riskyDepth = 2
+
+// confirmByName asks for the name of every target that is risky, or
+// larger than the configured threshold, to be typed back before anything
+// is deleted, and returns ErrNotConfirmed at the first one that isn't.
+// Dry runs never prompt.
+func (d *Deleter) confirmByName(ctx context.Context, roots []string) error {
+	if !d.config.ConfirmByName || d.config.DryRun {
+		return nil
+	}
+	for _, root := range roots {
+		reason := riskyTarget(root)
+		if reason == "" && d.config.ConfirmNameBytes > 0 {
+			if size := d.measure(ctx, root); size.Bytes > d.config.ConfirmNameBytes {
+				reason = "holds " + reporter.FormatBytes(size.Bytes)
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("%w: %w", ErrCancelled, err)
+		}
+		if reason == "" {
+			continue
+		}
+		if err := d.askName(root, reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// riskyTarget returns why deleting root deserves a second look: it is,
+// or contains, the user's home directory, or lies within riskyDepth
+// components of the filesystem root. It returns "" otherwise.
+func riskyTarget(root string) string {
+	resolved, err := resolvePath(root)
+	if err != nil {
+		return ""
+	}
+	if home, ok := homeDir(); ok && isWithin(resolved, home) {
+		return "contains your home directory"
+	}
+	if len(splitPath(resolved)) <= riskyDepth {
+		return "is close to the filesystem root"
+	}
+	return ""
+}
+
+// askName prompts for root's base name and returns ErrNotConfirmed
+// unless exactly that is typed.
+func (d *Deleter) askName(root, reason string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	name := filepath.Base(root)
+	if resolved, err := resolvePath(root); err == nil {
+		root, name = resolved, filepath.Base(resolved)
+	}
+	fmt.Fprintf(d.promptOut, "%s %s.\ntype %q to delete it: ", root, reason, name)
+	reply, err := d.promptIn.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if reply == "" {
+		fmt.Fprintln(d.promptOut)
+	}
+	if strings.TrimRight(reply, "\r\n") != name {
+		return fmt.Errorf("%w: %s", ErrNotConfirmed, root)
+	}
+	return nil
+}
+
+// sensitiveScan collects the setuid and setgid files and the entries of
+// other users that the pre-scan walks come across, see confirmSensitive.
+// Walks may overlap, so each path is counted once.
+type sensitiveScan struct {
+	uid     int
+	scanned bool
+	seen    map[string]bool
+	report  InspectReport
+}
+
+func (s *sensitiveScan) visit(path string, entry fs.DirEntry) {
+	s.scanned = true
+	if s.seen[path] {
+		return
+	}
+	info, err := entry.Info()
+	if err != nil {
+		return
+	}
+	if noteSensitive(&s.report, s.uid, path, info) {
+		s.seen[path] = true
+	}
+}
+
+// startSensitiveScan has the pre-scans of a run by root look out for
+// sensitive entries, with WithConfirmSensitive. Dry runs never prompt,
+// so they don't look.
+func (d *Deleter) startSensitiveScan() {
+	if !d.config.ConfirmSensitive || d.config.DryRun {
+		return
+	}
+	if uid := os.Geteuid(); uid == 0 {
+		d.sensitive = &sensitiveScan{uid: uid, seen: make(map[string]bool)}
+	}
+}
+
+// confirmSensitive lists the setuid or setgid files and files owned by
+// other users that the pre-scans found, if any, and asks once whether to
+// go ahead, returning ErrNotConfirmed if not. It does not walk the
+// targets itself: without a pre-scan, from WithConfirmLarge,
+// WithEstimate or WithConfirmByName, there is nothing to confirm. When the
+// prompt is not read from a terminal nobody can answer it, so the run
+// fails at once.
+func (d *Deleter) confirmSensitive() error {
+	s := d.sensitive
+	if s == nil || !s.scanned || s.report.Setuid == 0 && s.report.OtherOwners == 0 {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var summary strings.Builder
+	listSensitive(&summary, "setuid/setgid files", s.report.Setuid, s.report.SetuidPaths)
+	listSensitive(&summary, "entries owned by other users", s.report.OtherOwners, s.report.OtherOwned)
+	if !d.promptTTY {
+		return fmt.Errorf("%w: running as root, the targets hold:\n%s"+
+			"and there is no terminal to confirm deleting them (use --force to delete them anyway)",
+			ErrNotConfirmed, summary.String())
+	}
+	fmt.Fprintf(d.promptOut, "running as root, about to delete:\n%s", summary.String())
+	fmt.Fprint(d.promptOut, "proceed? [y/N] ")
+	reply, err := d.promptIn.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	switch strings.ToLower(strings.TrimSpace(reply)) {
+	case "y", "yes":
+		return nil
+	}
+	if reply == "" {
+		fmt.Fprintln(d.promptOut)
+	}
+	return ErrNotConfirmed
+}
+
+// listSensitive writes n and the first of the paths it counts to b.
+func listSensitive(b *strings.Builder, label string, n int, paths []string) {
+	if n == 0 {
+		return
+	}
+	fmt.Fprintf(b, "  %d %s\n", n, label)
+	for _, path := range paths {
+		fmt.Fprintf(b, "    %s\n", path)
+	}
+	if n > len(paths) {
+		fmt.Fprintf(b, "    ... and %d more\n", n-len(paths))
+	}
+}
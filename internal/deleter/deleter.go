@@ -1,16 +1,66 @@
 package deleter
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
 	"sync"
-	
+	"sync/atomic"
+	"time"
+
 	"github.com/yourusername/rmrf/internal/config"
 	"github.com/yourusername/rmrf/internal/reporter"
+	"github.com/yourusername/rmrf/internal/trash"
 )
 
 type Deleter struct {
-	config  *config.Options
-	stats   *reporter.Stats
-	mu      sync.Mutex
+	config *config.Options
+	stats  *reporter.Stats
+	fs     fileSystem
+	hasher *reporter.ManifestHasher
+	mu     sync.Mutex
+
+	trashLog *trash.Log
+	planMu   sync.Mutex
+
+	links   map[fileKey]*hardlink // files with several hard links, see addFreed
+	linksMu sync.Mutex
+
+	// protected holds the protected path patterns of the options and
+	// of config.SystemProtectedFile; protectedErr is why the latter
+	// could not be read, which refuses every target.
+	protected    []string
+	protectedErr error
+
+	preview planSet // see WithResultDiffAgainstPreview; nil without
+
+	sensitive *sensitiveScan // see confirmSensitive; nil when not looking
+
+	audit      *auditLog     // see WithAuditLog; nil outside a run
+	archive    *archive      // see WithArchive; nil outside a run
+	checkpoint *checkpoint   // see WithCheckpoint; nil outside a run
+	retries    retryQueue    // see WithRetries
+	backoff    time.Duration // before the first retry round, see tune
+	adaptive   *adaptive     // see WithAdaptiveThreads; nil outside a run
+	held       atomic.Int32  // semaphore slots held back from the workers
+	limiter    *rateLimiter  // see WithRateLimit; nil without limits
+
+	yesToAll atomic.Bool // an interactive "a" answer
+	quitting atomic.Bool // an interactive "q" answer
+
+	writers   *writerWatch // see WithWatchWrites; nil outside a run
+	passAgain atomic.Bool  // see WithUntilEmpty
+	lastPass  atomic.Bool
+
+	promptIn  *bufio.Reader
+	promptOut io.Writer
+	promptTTY bool // whether someone can be at the other end of promptIn
 }
 
 func New(opts ...config.Option) *Deleter {
@@ -18,31 +68,454 @@ func New(opts ...config.Option) *Deleter {
 	for _, opt := range opts {
 		opt(&cfg)
 	}
-	
+
+	var fsys fileSystem = osFileSystem{}
+	switch {
+	case cfg.DryRun:
+		fsys = dryRunFileSystem{}
+	case cfg.Root != nil:
+		fsys = rootFileSystem{cfg.Root}
+	}
+
+	stats := reporter.DefaultStats()
+	stats.OnError = cfg.OnError
+	if cfg.Events != nil {
+		stats.OnError = func(err error) {
+			if cfg.OnError != nil {
+				cfg.OnError(err)
+			}
+			cfg.Events(reporter.Event{Type: reporter.EventError, Error: err.Error()})
+		}
+	}
+
+	var hasher *reporter.ManifestHasher
+	if cfg.ResultHash {
+		hasher = &reporter.ManifestHasher{}
+	}
+
+	if cfg.Reporter == nil {
+		cfg.Reporter = reporter.NewTerminalReporter(os.Stdout)
+	}
+
+	var in io.Reader = os.Stdin
+	if cfg.PromptIn != nil {
+		in = cfg.PromptIn
+	}
+	var out io.Writer = os.Stdout
+	if cfg.PromptOut != nil {
+		out = cfg.PromptOut
+	}
+
+	var preview planSet
+	if cfg.Preview != nil {
+		preview = newPlanSet(cfg.Preview)
+	}
+
+	admin, err := loadAdminProtected()
+	if err != nil {
+		err = fmt.Errorf("reading %s: %w", config.SystemProtectedFile, err)
+	}
+
 	return &Deleter{
-		config: &cfg,
-		stats:  reporter.DefaultStats(),
+		config:       &cfg,
+		stats:        stats,
+		fs:           fsys,
+		hasher:       hasher,
+		protected:    append(slices.Clone(cfg.ProtectedPaths), admin...),
+		protectedErr: err,
+		preview:      preview,
+		limiter:      newRateLimiter(cfg.RateEntries, cfg.RateBytes),
+		promptIn:     bufio.NewReader(in),
+		promptOut:    out,
+		promptTTY:    isTerminal(in),
+	}
+}
+
+// isTerminal reports whether in is a terminal. Readers other than files
+// are taken to be answered by whoever supplied them.
+func isTerminal(in io.Reader) bool {
+	f, ok := in.(*os.File)
+	if !ok {
+		return true
 	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
 }
 
 func (d *Deleter) Delete(path string) (*reporter.Stats, error) {
+	return d.DeleteContext(context.Background(), path)
+}
+
+// DeleteContext is like Delete but stops early once ctx is done. Workers
+// finish the entry they are on, no new directories are read or goroutines
+// started, and the stats collected so far are returned with an error that
+// matches both ErrCancelled and ctx.Err().
+func (d *Deleter) DeleteContext(ctx context.Context, path string) (*reporter.Stats, error) {
 	if err := d.validatePath(path); err != nil {
 		return nil, err
 	}
-	
-	absPath, err := filepath.Abs(path)
+	return d.deleteTargets(ctx, []string{path})
+}
+
+// DeleteMany deletes several targets in one run, accumulating into a single
+// Stats. Arguments containing glob metacharacters are expanded, with "**"
+// matching any number of directories, unless WithNoGlob is set. A pattern
+// that matches nothing or a target that fails validation is recorded in
+// Stats.Errors and skipped; the remaining targets are still deleted.
+func (d *Deleter) DeleteMany(paths ...string) (*reporter.Stats, error) {
+	return d.DeleteManyContext(context.Background(), paths...)
+}
+
+// DeleteManyContext is DeleteMany with cancellation, see DeleteContext.
+func (d *Deleter) DeleteManyContext(ctx context.Context, paths ...string) (*reporter.Stats, error) {
+	var targets []string
+	for _, arg := range paths {
+		matches, err := d.expandTarget(arg)
+		if err != nil {
+			d.stats.AddError(err)
+			continue
+		}
+		for _, path := range matches {
+			if err := d.validatePath(path); err != nil {
+				d.stats.AddError(fmt.Errorf("%s: %w", path, err))
+				continue
+			}
+			targets = append(targets, path)
+		}
+	}
+	return d.deleteTargets(ctx, targets)
+}
+
+// deleteTargets deletes already validated targets concurrently. Targets
+// and every directory below them are tasks for one shared pool of
+// workers, so together they never exceed the configured concurrency.
+func (d *Deleter) deleteTargets(ctx context.Context, paths []string) (*reporter.Stats, error) {
+	if d.config.Trash && (d.filtering() || d.config.Interactive) {
+		return nil, ErrTrashFilters
+	}
+	if d.config.Trash && d.config.Root != nil {
+		return nil, ErrTrashRoot
+	}
+	if d.config.Trash && d.config.ShredPasses > 0 {
+		return nil, ErrTrashShred
+	}
+	if d.config.Trash && d.config.Archive != "" {
+		return nil, ErrTrashArchive
+	}
+	if d.config.Detach && (d.filtering() || d.config.Interactive || d.config.Archive != "" || d.config.Trash) {
+		return nil, ErrDetachFilters
+	}
+	if err := validatePatterns(d.config.IncludeOnly); err != nil {
+		return nil, err
+	}
+	if err := validatePatterns(d.config.Excludes); err != nil {
+		return nil, err
+	}
+	paths, err := d.selectTargets(ctx, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	roots, err := d.dedupeTargets(paths)
+	if err != nil {
+		return nil, err
+	}
+	for _, root := range roots {
+		if err := d.preflight(root); err != nil {
+			return nil, err
+		}
+	}
+	if len(roots) == 0 {
+		return d.stats, nil
+	}
+	d.startSensitiveScan()
+	if err := d.confirmByName(ctx, roots); err != nil {
+		return nil, err
+	}
+	// Taken before anything is touched, so the walk is a pre-scan.
+	estimate := -1
+	if d.config.Estimate {
+		estimate = d.estimateEntries(ctx, roots)
+	}
+	if err := d.confirmLarge(ctx, roots); err != nil {
+		return nil, err
+	}
+	if err := d.confirmSensitive(); err != nil {
+		return nil, err
+	}
+	if err := d.snapshotTargets(ctx, roots); err != nil {
+		return nil, err
+	}
+	if d.config.Detach {
+		if roots = d.detachTargets(ctx, roots); len(roots) == 0 {
+			return d.stats, nil
+		}
+	}
+	if d.config.Handoff != nil && !d.config.DryRun {
+		return d.stats, d.config.Handoff(roots)
+	}
+
+	stopAudit, err := d.startAudit()
+	if err != nil {
+		return nil, err
+	}
+	defer stopAudit()
+
+	stopArchive, err := d.startArchive(roots)
 	if err != nil {
 		return nil, err
 	}
+	defer stopArchive()
 
-	var wg sync.WaitGroup
-	sem := make(chan struct{}, d.config.MaxThreads)
-	progress := reporter.NewProgressReporter(0) // Initialize with 0, will update during traversal
+	if err := d.openTrashLog(); err != nil {
+		return nil, err
+	}
+	if d.trashLog != nil {
+		defer d.trashLog.Close()
+	}
+
+	tuned := d.tune(roots[0])
+	threads := tuned.threads
+	d.stats.Threads, d.stats.Filesystem, d.stats.Profile = threads, tuned.fs, string(tuned.profile)
+	d.backoff = tuned.backoff
+	defer d.startEngine()()
+
+	sem := make(chan struct{}, threads)
+	progress := reporter.NewProgressReporter(0, d.config.Reporter) // Initialize with 0, will update during traversal
+	if estimate >= 0 {
+		progress.SetEstimate(estimate)
+	} else if d.config.EstimateFromStatfs {
+		if n, ok := usedInodes(roots[0]); ok {
+			progress.SetEstimate(n)
+		}
+	}
+
+	defer d.startAdaptive(sem)()
+	if d.config.AdaptiveLoad > 0 || d.config.MaxPressure > 0 {
+		// Stopped with the run too, so a pause does not outlast it.
+		loadCtx, stopLoad := context.WithCancel(ctx)
+		var wg sync.WaitGroup
+		wg.Go(func() { d.throttleOnLoad(sem, loadCtx.Done()) })
+		defer func() {
+			stopLoad()
+			wg.Wait()
+		}()
+	}
 
-	wg.Add(1)
-	go d.deleteRecursive(absPath, &wg, sem, progress)
-	wg.Wait()
+	measure := d.config.CompareFreeSpace && !d.config.DryRun
+	if measure {
+		d.stats.FreeBefore, _ = freeBytes(roots[0])
+	}
+
+	start := time.Now()
+	defer func() {
+		d.stats.Duration = time.Since(start)
+		if d.hasher != nil {
+			d.hashManifest()
+		}
+	}()
+
+	stopCheckpoint, err := d.startCheckpoint(roots)
+	if err != nil {
+		return nil, err
+	}
+
+	stopWatch, err := d.startWriterWatch(cancel)
+	if err != nil {
+		stopCheckpoint(false)
+		return nil, err
+	}
+
+	counts := make([]*reporter.RootStats, len(roots))
+	for i, root := range roots {
+		counts[i] = d.stats.AddRoot(root)
+	}
+	var runs []*run
+	d.lastPass.Store(false)
+	for pass := 1; ; pass++ {
+		if pass >= d.config.UntilEmpty {
+			d.lastPass.Store(true)
+		}
+		before := d.stats.Snapshot()
+		workers := newPool(threads, sem)
+		for i, root := range roots {
+			if _, err := os.Lstat(root); pass > 1 && os.IsNotExist(err) {
+				continue // gone with an earlier pass
+			}
+			r := &run{ctx: ctx, cancel: cancel, root: root, counts: counts[i], pool: workers, progress: progress, started: start}
+			runs = append(runs, r)
+			workers.submit(func() { d.deleteRoot(r) })
+		}
+		workers.wait()
+		d.retryTransient(ctx.Done())
+		if !d.anotherPass(ctx, pass, before) {
+			break
+		}
+	}
+	writersErr := stopWatch()
 	progress.Complete()
+	stopCheckpoint(ctx.Err() == nil && !d.quitting.Load() && d.stats.Snapshot().Errors == 0)
+	d.checkSymlinkFarm()
+
+	// Quitting at a prompt stops the run early too, but is the user's
+	// answer rather than an interruption.
+	if err := parent.Err(); err != nil {
+		return d.stats, fmt.Errorf("%w: %w", ErrCancelled, err)
+	}
+	if d.quitting.Load() {
+		return d.stats, nil
+	}
 
-	return d.stats, nil
+	if measure {
+		d.stats.FreeAfter, _ = freeBytes(filepath.Dir(roots[0]))
+	}
+
+	errs := []error{writersErr}
+	for _, r := range runs {
+		errs = append(errs, r.err)
+	}
+	return d.stats, errors.Join(errs...)
+}
+
+// deleteRoot starts deleting a single target. Unlike clearDir it accepts
+// plain files, and it runs the barrier hook between emptying a directory
+// target and removing it, leaving the hook's error in r.err. The work on a
+// directory continues on the pool after deleteRoot returns.
+func (d *Deleter) deleteRoot(r *run) {
+	if r.ctx.Err() != nil {
+		return
+	}
+	if d.config.Trash && d.trashRoot(r) {
+		return
+	}
+
+	lstat, err := os.Lstat(r.root)
+	if err != nil {
+		d.fail(r, err)
+		return
+	}
+	info := lstat
+	link := isReparsePoint(fs.FileInfoToDirEntry(lstat))
+	if link {
+		switch d.config.SymlinkPolicy {
+		case config.SymlinkSkip:
+			d.stats.AddSymlinkSkipped()
+			d.emitSkipped(r.root, "symlink")
+			d.fail(r, fmt.Errorf("skipped symlink: %s", r.root))
+			return
+		case config.SymlinkFollow:
+			if info, err = os.Stat(r.root); err != nil {
+				d.fail(r, err)
+				return
+			}
+		}
+	}
+	if d.config.SymlinkPolicy == config.SymlinkFollow {
+		if r.realRoot, err = resolvePath(r.root); err != nil {
+			d.fail(r, err)
+			return
+		}
+	}
+	if r.absRoot, err = filepath.Abs(r.root); err != nil {
+		d.fail(r, err)
+		return
+	}
+	if d.protectedEntry(r, r.root) {
+		d.stats.AddKept()
+		d.emitSkipped(r.root, "protected")
+		return
+	}
+	if d.unplanned(r.root) {
+		d.stats.AddKept()
+		return
+	}
+	d.shredTarget(r)
+	var ino uint64
+	r.dev, ino, r.devKnown = fileID(info)
+
+	if ok, _ := d.approve(r, r.root, info.IsDir()); !ok {
+		if !d.quitting.Load() {
+			d.stats.AddSkipped(r.root)
+			d.emitSkipped(r.root, "declined")
+		}
+		return
+	}
+	if !info.IsDir() || link && d.config.SymlinkPolicy != config.SymlinkFollow {
+		if d.leaveSpecial(r, r.root, fs.FileInfoToDirEntry(info)) {
+			return
+		}
+		if d.config.PruneEmpty {
+			d.stats.AddKept()
+			d.emitSkipped(r.root, "filtered")
+			return
+		}
+		r.progress.AddTotal(1)
+		d.processFile(r, nil, r.root, fs.FileInfoToDirEntry(info))
+		r.progress.Update(1)
+		return
+	}
+
+	root := newDirTask(r.root, nil)
+	root.dev, root.ino = r.dev, ino
+	root.finish = func(t *dirTask) {
+		if d.config.KeepRoot {
+			return
+		}
+		if r.err = d.passBarrier(r.root); r.err != nil {
+			return
+		}
+		switch {
+		case link:
+			d.processFile(r, nil, r.root, fs.FileInfoToDirEntry(lstat))
+		case !isSubvolume(r.root, ino):
+			d.removeDir(r, nil, r.root)
+		case d.config.DeleteSubvolumes:
+			d.removeSubvolume(r, nil, r.root)
+		default:
+			d.stats.AddSubvolumeSkipped()
+			d.emitSkipped(r.root, "btrfs subvolume")
+		}
+	}
+	d.clearDir(r, root)
+}
+
+// passBarrier gives the configured barrier hook a last chance to stop the
+// run after the contents of root are gone but before root itself is.
+func (d *Deleter) passBarrier(root string) error {
+	if d.config.Barrier == nil {
+		return nil
+	}
+	if err := d.config.Barrier(root, d.stats); err != nil {
+		return fmt.Errorf("stopped before removing %s: %w", root, err)
+	}
+	return nil
+}
+
+// startEngine switches removals over to the configured engine for one
+// run and returns the function that switches back. An engine that can't
+// be used falls back to the standard one with a warning.
+func (d *Deleter) startEngine() (stop func()) {
+	if d.config.Engine != config.EngineURing || d.config.DryRun {
+		return func() {}
+	}
+	if d.config.Root != nil {
+		d.stats.AddWarning("uring engine cannot be confined to a root, using the standard engine")
+		return func() {}
+	}
+
+	ring, err := newURing()
+	if err != nil {
+		d.stats.AddWarning(fmt.Sprintf("uring engine unavailable, using the standard engine: %v", err))
+		return func() {}
+	}
+	prev := d.fs
+	d.fs = uringFileSystem{ring: ring}
+	return func() {
+		d.fs = prev
+		ring.close()
+	}
 }
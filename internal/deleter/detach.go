@@ -0,0 +1,74 @@
+package deleter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+)
+
+// detachAttempts is how many random names detach tries before giving up.
+const detachAttempts = 16
+
+// maxNameLen is the longest file name detach makes, NAME_MAX on most
+// filesystems.
+const maxNameLen = 255
+
+// detachTargets renames each root aside, to a hidden name next to it, so
+// that the path is free again at once, and returns where they went. A
+// root that can't be moved is reported and dropped; the rest are still
+// moved, unless ctx is done. A dry run moves nothing and returns roots as
+// they are.
+func (d *Deleter) detachTargets(ctx context.Context, roots []string) []string {
+	if d.config.DryRun {
+		return roots
+	}
+	var moved []string
+	for _, root := range roots {
+		aside, err := d.detach(ctx, root)
+		if err != nil {
+			d.stats.AddError(err)
+			continue
+		}
+		moved = append(moved, aside)
+	}
+	return moved
+}
+
+// detach renames root to a free name of the form .<name>.rmrf-<pid>-<random>
+// in the same directory, and so on the same filesystem, where the rename
+// is atomic. name is shortened as needed for that to fit in maxNameLen.
+// The rename itself refuses to replace anything, see fileSystem.Rename,
+// and another random name is tried if one is taken.
+func (d *Deleter) detach(ctx context.Context, root string) (string, error) {
+	if _, err := os.Lstat(filepath.Join(root, ignoreFileName)); err == nil {
+		return "", fmt.Errorf("%s: %w", root, ErrDetachIgnore)
+	}
+	dir, name := filepath.Split(root)
+	suffix := fmt.Sprintf(".rmrf-%d-", os.Getpid())
+	if n := maxNameLen - len(".") - len(suffix) - len("01234567"); len(name) > n {
+		for n > 0 && !utf8.RuneStart(name[n]) {
+			n--
+		}
+		name = name[:n]
+	}
+	for range detachAttempts {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		aside := filepath.Join(dir, fmt.Sprintf(".%s%s%08x", name, suffix, rand.Uint32()))
+		err := d.fs.Rename(root, aside)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return aside, nil
+	}
+	return "", fmt.Errorf("%s: no free name to detach it to after %d attempts", root, detachAttempts)
+}
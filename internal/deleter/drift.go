@@ -0,0 +1,67 @@
+package deleter
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/yourusername/rmrf/internal/reporter"
+)
+
+// planSet holds the paths of a plan, made absolute, to tell entries the
+// tree gained since the plan was made from those it lists.
+type planSet map[string]bool
+
+func newPlanSet(plan []reporter.PlanEntry) planSet {
+	s := make(planSet, len(plan))
+	for _, entry := range plan {
+		s[absPath(entry.Path)] = true
+	}
+	return s
+}
+
+func (s planSet) has(path string) bool {
+	return s[absPath(path)]
+}
+
+// absPath returns path made absolute and clean, or just clean if that
+// fails.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// unplanned reports whether path is missing from the plan the run is
+// checked against, see WithResultDiffAgainstPreview, and if so records it
+// as drift.
+func (d *Deleter) unplanned(path string) bool {
+	if d.preview == nil || d.preview.has(path) {
+		return false
+	}
+	d.stats.AddDrift(path)
+	d.emitSkipped(path, "unplanned")
+	return true
+}
+
+// keepDrifted records as drift every entry of the directory at path that
+// is missing from plan, and reports whether there were any, in which case
+// the directory is to be kept.
+func (d *Deleter) keepDrifted(plan planSet, path string) bool {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return false
+	}
+	drifted := false
+	for _, entry := range entries {
+		child := filepath.Join(path, entry.Name())
+		if plan.has(child) {
+			continue
+		}
+		d.stats.AddKept()
+		d.stats.AddDrift(child)
+		d.emitSkipped(child, "unplanned")
+		drifted = true
+	}
+	return drifted
+}
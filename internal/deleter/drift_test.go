@@ -0,0 +1,111 @@
+package deleter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/yourusername/rmrf/internal/config"
+	"github.com/yourusername/rmrf/internal/reporter"
+)
+
+// driftTree creates a tree, takes the plan of a dry run deleting it, and
+// then adds entries the plan does not know of. It returns the target and
+// the plan.
+func driftTree(t *testing.T) (string, []reporter.PlanEntry) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "target")
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{"a", "sub/b"} {
+		if err := os.WriteFile(filepath.Join(dir, f), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var plan []reporter.PlanEntry
+	d := New(
+		config.WithReporter(reporter.NoopReporter{}),
+		config.WithDryRun(true),
+		config.WithPlan(func(e reporter.PlanEntry) { plan = append(plan, e) }),
+	)
+	if _, err := d.Delete(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "newdir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{"new", "sub/new", "newdir/x"} {
+		if err := os.WriteFile(filepath.Join(dir, f), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir, plan
+}
+
+// checkDrift checks that what was added after the plan, and the
+// directories holding it, were kept and reported, and the rest deleted.
+func checkDrift(t *testing.T, dir string, stats *reporter.Stats) {
+	t.Helper()
+	if len(stats.Errors) > 0 {
+		t.Errorf("errors: %v", stats.Errors)
+	}
+	var drift []string
+	for _, path := range stats.Drift {
+		rel, _ := filepath.Rel(dir, path)
+		drift = append(drift, filepath.ToSlash(rel))
+	}
+	slices.Sort(drift)
+	if want := []string{"new", "newdir", "sub/new"}; !slices.Equal(drift, want) {
+		t.Errorf("drift %q, want %q", drift, want)
+	}
+	want := []string{"new", "newdir", "newdir/x", "sub", "sub/new"}
+	if got := remaining(t, dir); !slices.Equal(got, want) {
+		t.Errorf("left %q, want %q", got, want)
+	}
+}
+
+func TestDiffAgainstPreview(t *testing.T) {
+	dir, plan := driftTree(t)
+	d := New(
+		config.WithReporter(reporter.NoopReporter{}),
+		config.WithResultDiffAgainstPreview(plan),
+	)
+	stats, err := d.Delete(dir)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	checkDrift(t, dir, stats)
+}
+
+func TestDiffAgainstEmptyPreview(t *testing.T) {
+	dir, _ := driftTree(t)
+	d := New(
+		config.WithReporter(reporter.NoopReporter{}),
+		config.WithResultDiffAgainstPreview([]reporter.PlanEntry{}),
+	)
+	stats, err := d.Delete(dir)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if !slices.Equal(stats.Drift, []string{dir}) {
+		t.Errorf("drift %q, want the target alone", stats.Drift)
+	}
+	if _, err := os.Lstat(filepath.Join(dir, "a")); err != nil {
+		t.Errorf("entry of an unplanned target removed: %v", err)
+	}
+}
+
+func TestApplyReportsDrift(t *testing.T) {
+	dir, plan := driftTree(t)
+	d := New(config.WithReporter(reporter.NoopReporter{}))
+	stats, err := d.Apply(context.Background(), plan)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	checkDrift(t, dir, stats)
+}
@@ -0,0 +1,151 @@
+package deleter
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/yourusername/rmrf/internal/config"
+	"github.com/yourusername/rmrf/internal/reporter"
+	"github.com/yourusername/rmrf/internal/trash"
+)
+
+// failingFS is a fileSystem that records every call and fails it.
+type failingFS struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (f *failingFS) call(op, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, op+" "+name)
+	return fs.ErrPermission
+}
+
+func (f *failingFS) Chmod(name string, mode os.FileMode) error { return f.call("Chmod", name) }
+func (f *failingFS) ChmodAt(dir *os.File, name string, mode os.FileMode) error {
+	return f.call("ChmodAt", filepath.Join(dir.Name(), name))
+}
+func (f *failingFS) Remove(name string) error { return f.call("Remove", name) }
+func (f *failingFS) RemoveAt(dir *os.File, name string, isDir bool) error {
+	return f.call("RemoveAt", filepath.Join(dir.Name(), name))
+}
+func (f *failingFS) Trash(name string) (trash.Item, error) {
+	return trash.Item{}, f.call("Trash", name)
+}
+func (f *failingFS) ClearAttrs(file *os.File) error      { return f.call("ClearAttrs", file.Name()) }
+func (f *failingFS) DeferRemove(name string) error       { return f.call("DeferRemove", name) }
+func (f *failingFS) Shred(name string, passes int) error { return f.call("Shred", name) }
+func (f *failingFS) RemoveSubvolume(dir *os.File, name string) error {
+	return f.call("RemoveSubvolume", filepath.Join(dir.Name(), name))
+}
+func (f *failingFS) Rename(oldname, newname string) error { return f.call("Rename", oldname) }
+
+// makeTree creates a small tree under dir: nested directories, a
+// read-only file, an unwritable directory and a symlink.
+func makeTree(t *testing.T, dir string) {
+	t.Helper()
+	for _, d := range []string{"a/b/c", "ro", "empty"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range []string{"top.txt", "a/one.log", "a/b/two.txt", "a/b/c/three.txt", "ro/locked.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, f), []byte(f), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chmod(filepath.Join(dir, "a/one.log"), 0444); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("a/b", filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(dir, "ro"), 0555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(filepath.Join(dir, "ro"), 0755) })
+}
+
+// snapshotTree returns the path and mode of everything under dir.
+func snapshotTree(t *testing.T, dir string) map[string]fs.FileMode {
+	t.Helper()
+	tree := make(map[string]fs.FileMode)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		tree[path] = info.Mode()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func TestDryRunMakesNoMutatingCalls(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []config.Option
+	}{
+		{"default", nil},
+		{"chmod always", []config.Option{config.WithChmodPolicy(config.ChmodAlways)}},
+		{"trash", []config.Option{config.WithTrash(true)}},
+		{"shred", []config.Option{config.WithShred(3)}},
+		{"detach", []config.Option{config.WithDetach(true)}},
+		{"keep root", []config.Option{config.WithKeepRoot(true)}},
+		{"clear attrs", []config.Option{config.WithClearAttrs(true)}},
+		{"follow symlinks", []config.Option{config.WithSymlinkPolicy(config.SymlinkFollow)}},
+		{"filtered", []config.Option{config.WithIncludeOnly("*.txt"), config.WithExcludes([]string{"c"})}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "target")
+			makeTree(t, dir)
+			before := snapshotTree(t, dir)
+
+			opts := append([]config.Option{
+				config.WithDryRun(true),
+				config.WithReporter(reporter.NoopReporter{}),
+			}, tt.opts...)
+			d := New(opts...)
+			spy := &failingFS{}
+			d.fs = spy
+
+			stats, err := d.Delete(dir)
+			if err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if len(spy.calls) > 0 {
+				t.Errorf("dry run made mutating calls: %v", spy.calls)
+			}
+			if stats.FilesDeleted == 0 && len(stats.Trashed) == 0 {
+				t.Errorf("dry run accounted for nothing")
+			}
+			after := snapshotTree(t, dir)
+			if len(after) != len(before) {
+				t.Errorf("tree changed: %d entries before, %d after", len(before), len(after))
+			}
+			for path, mode := range before {
+				if after[path] != mode {
+					t.Errorf("%s: mode %v before, %v after", path, mode, after[path])
+				}
+			}
+		})
+	}
+}
+
+func TestDryRunUsesInertFileSystem(t *testing.T) {
+	d := New(config.WithDryRun(true))
+	if _, ok := d.fs.(dryRunFileSystem); !ok {
+		t.Errorf("dry run fileSystem is %T, want dryRunFileSystem", d.fs)
+	}
+}
@@ -0,0 +1,54 @@
+package deleter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/yourusername/rmrf/internal/reporter"
+)
+
+// erasureHash returns the SHA-256 of the regular file at path, described
+// by info, for the erasure record, or "" if none is wanted. It fails if
+// the file can't be read or was replaced since info was taken.
+func (d *Deleter) erasureHash(path string, entry os.DirEntry, info os.FileInfo) (string, error) {
+	if d.config.Erasure == nil || !d.config.ErasureHash || !entry.Type().IsRegular() || info == nil {
+		return "", nil
+	}
+	f, err := openSame(path, info)
+	if err != nil {
+		return "", fmt.Errorf("hashing %s: %w", path, err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("hashing %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// recordErasure passes a deleted entry to the erasure callback, under
+// the same lock as the plan callback.
+func (d *Deleter) recordErasure(path string, info os.FileInfo, sum string) {
+	if d.config.Erasure == nil || info == nil {
+		return
+	}
+	entry := reporter.ErasureEntry{
+		Path:      path,
+		Type:      entryType(info),
+		Size:      info.Size(),
+		Mtime:     info.ModTime(),
+		DeletedAt: time.Now(),
+		SHA256:    sum,
+	}
+	if info.IsDir() {
+		entry.Size = 0
+	}
+
+	d.planMu.Lock()
+	defer d.planMu.Unlock()
+	d.config.Erasure(entry)
+}
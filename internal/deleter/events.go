@@ -0,0 +1,21 @@
+package deleter
+
+import "github.com/yourusername/rmrf/internal/reporter"
+
+// emit passes an event to the configured event callback, if any, and
+// records it in the audit log.
+func (d *Deleter) emit(typ, path string) {
+	d.auditRemoved(typ, path)
+	if d.config.Events != nil {
+		d.config.Events(reporter.Event{Type: typ, Path: path})
+	}
+}
+
+// emitSkipped reports a path left in place and why, to the event callback
+// and the manifest.
+func (d *Deleter) emitSkipped(path, reason string) {
+	d.recordManifest(path, nil, reporter.StatusSkipped, reason)
+	if d.config.Events != nil {
+		d.config.Events(reporter.Event{Type: reporter.EventSkipped, Path: path, Reason: reason})
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !linux && !darwin
+
+package deleter
+
+import "os"
+
+func fileID(info os.FileInfo) (dev, ino uint64, ok bool) {
+	return 0, 0, false
+}
+
+func fileOwner(info os.FileInfo) (uid, gid int, ok bool) {
+	return 0, 0, false
+}
+
+func linkCount(info os.FileInfo) uint64 {
+	return 1
+}
+
+func allocatedBytes(info os.FileInfo) int64 {
+	return info.Size()
+}
@@ -0,0 +1,158 @@
+package deleter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// validatePatterns rejects malformed glob patterns up front rather than
+// letting every match during traversal fail silently.
+func validatePatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// matchAny reports whether path matches one of patterns. Patterns without
+// a separator are matched against the base name, so "*.log" applies at any
+// depth; patterns with one are matched against the path relative to root.
+func matchAny(patterns []string, root, path string) bool {
+	name, rel := matchTargets(root, path)
+	for _, p := range patterns {
+		target := name
+		if strings.Contains(p, "/") {
+			target = rel
+		}
+		if ok, _ := filepath.Match(p, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAnyRegexp is matchAny for regular expressions.
+func matchAnyRegexp(res []*regexp.Regexp, root, path string) bool {
+	name, rel := matchTargets(root, path)
+	for _, re := range res {
+		target := name
+		if strings.Contains(re.String(), "/") {
+			target = rel
+		}
+		if re.MatchString(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTargets returns the base name of path and its path relative to
+// root with slashes, which patterns are matched against.
+func matchTargets(root, path string) (name, rel string) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = path
+	}
+	return filepath.Base(path), filepath.ToSlash(rel)
+}
+
+// keepEntry reports whether the filters leave the entry at path, depth
+// levels below the target, in place. Include-only patterns pick which
+// files are candidates; excludes then carve files or whole subtrees out of
+// that set.
+func (d *Deleter) keepEntry(r *run, path string, depth int, entry os.DirEntry) bool {
+	if d.config.MaxDepth > 0 && depth > d.config.MaxDepth {
+		return true
+	}
+	if d.config.PruneEmpty && (!entry.IsDir() || isReparsePoint(entry)) {
+		return true
+	}
+	if !entry.IsDir() && depth < d.config.MinDepth {
+		return true
+	}
+	if !entry.IsDir() && !d.included(r, path) {
+		return true
+	}
+	if !entry.IsDir() && (!d.inAgeRange(r, entry) || !d.inSizeRange(entry) || !d.ownedAsAsked(entry)) {
+		return true
+	}
+	return matchAny(d.config.Excludes, r.root, path)
+}
+
+// filtering reports whether any filter may keep part of a target.
+func (d *Deleter) filtering() bool {
+	return len(d.config.IncludeOnly) > 0 || len(d.config.IncludeRegexps) > 0 || len(d.config.Excludes) > 0 ||
+		d.config.OlderThan > 0 || d.config.NewerThan > 0 ||
+		d.config.LargerThan > 0 || d.config.SmallerThan > 0 ||
+		len(d.config.OwnerUIDs) > 0 || len(d.config.GroupGIDs) > 0 ||
+		d.config.MinDepth > 0 || d.config.MaxDepth > 0 || d.config.KeepRoot ||
+		d.config.PruneEmpty || d.config.SkipCacheDirs || d.config.Preview != nil
+}
+
+// included reports whether the file at path is a candidate for deletion
+// under the include globs and regular expressions, which is any file if
+// there are none.
+func (d *Deleter) included(r *run, path string) bool {
+	if len(d.config.IncludeOnly) == 0 && len(d.config.IncludeRegexps) == 0 {
+		return true
+	}
+	return matchAny(d.config.IncludeOnly, r.root, path) || matchAnyRegexp(d.config.IncludeRegexps, r.root, path)
+}
+
+// ownedAsAsked reports whether entry's owner and group are among those
+// WithOwners and WithGroups ask for.
+func (d *Deleter) ownedAsAsked(entry os.DirEntry) bool {
+	if len(d.config.OwnerUIDs) == 0 && len(d.config.GroupGIDs) == 0 {
+		return true
+	}
+	info, err := entry.Info()
+	if err != nil {
+		return true
+	}
+	uid, gid, ok := fileOwner(info)
+	if !ok {
+		return false
+	}
+	return (len(d.config.OwnerUIDs) == 0 || slices.Contains(d.config.OwnerUIDs, uid)) &&
+		(len(d.config.GroupGIDs) == 0 || slices.Contains(d.config.GroupGIDs, gid))
+}
+
+// inSizeRange reports whether entry is as large as WithLargerThan and
+// WithSmallerThan ask. Sizes are apparent sizes, as ls shows them.
+func (d *Deleter) inSizeRange(entry os.DirEntry) bool {
+	if d.config.LargerThan <= 0 && d.config.SmallerThan <= 0 {
+		return true
+	}
+	info, err := entry.Info()
+	if err != nil {
+		return true
+	}
+	if d.config.LargerThan > 0 && info.Size() <= d.config.LargerThan {
+		return false
+	}
+	return d.config.SmallerThan <= 0 || info.Size() < d.config.SmallerThan
+}
+
+// inAgeRange reports whether entry is as old as WithOlderThan and
+// WithNewerThan ask, measured from the start of the run. An entry that
+// can no longer be stat'ed is left to fail at removal.
+func (d *Deleter) inAgeRange(r *run, entry os.DirEntry) bool {
+	if d.config.OlderThan <= 0 && d.config.NewerThan <= 0 {
+		return true
+	}
+	info, err := entry.Info()
+	if err != nil {
+		return true
+	}
+	age := r.started.Sub(entryTime(info, d.config.AgeTime))
+	if d.config.OlderThan > 0 && age <= d.config.OlderThan {
+		return false
+	}
+	return d.config.NewerThan <= 0 || age < d.config.NewerThan
+}
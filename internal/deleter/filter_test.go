@@ -0,0 +1,116 @@
+package deleter
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/yourusername/rmrf/internal/config"
+	"github.com/yourusername/rmrf/internal/reporter"
+)
+
+// remaining returns the slash-separated paths left below dir, sorted.
+func remaining(t *testing.T, dir string) []string {
+	t.Helper()
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir {
+			rel, _ := filepath.Rel(dir, path)
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// TestIncludeExclude checks how WithIncludeOnly and WithExcludes combine:
+// include-only patterns pick the files that are candidates, then excludes
+// carve files and whole subtrees out of them.
+func TestIncludeExclude(t *testing.T) {
+	files := []string{
+		"a.log", "a.txt",
+		"keep/b.log", "keep/b.txt",
+		"sub/c.log", "sub/c.txt",
+		"logs/d.log",
+	}
+	tests := []struct {
+		name     string
+		includes []string
+		excludes []string
+		want     []string
+	}{
+		{
+			name:     "include only",
+			includes: []string{"*.log"},
+			want:     []string{"a.txt", "keep", "keep/b.txt", "sub", "sub/c.txt"},
+		},
+		{
+			name:     "include by relative path",
+			includes: []string{"sub/*"},
+			want:     []string{"a.log", "a.txt", "keep", "keep/b.log", "keep/b.txt", "logs", "logs/d.log"},
+		},
+		{
+			name:     "exclude only",
+			excludes: []string{"*.log"},
+			want:     []string{"a.log", "keep", "keep/b.log", "logs", "logs/d.log", "sub", "sub/c.log"},
+		},
+		{
+			name:     "excluded directory",
+			excludes: []string{"keep"},
+			want:     []string{"keep", "keep/b.log", "keep/b.txt"},
+		},
+		{
+			name:     "include and exclude match the same file",
+			includes: []string{"*.log"},
+			excludes: []string{"a.log"},
+			want:     []string{"a.log", "a.txt", "keep", "keep/b.txt", "sub", "sub/c.txt"},
+		},
+		{
+			name:     "excluded directory holds included files",
+			includes: []string{"*.log"},
+			excludes: []string{"keep"},
+			want:     []string{"a.txt", "keep", "keep/b.log", "keep/b.txt", "sub", "sub/c.txt"},
+		},
+		{
+			name:     "exclude outside the included files",
+			includes: []string{"*.log"},
+			excludes: []string{"*.txt"},
+			want:     []string{"a.txt", "keep", "keep/b.txt", "sub", "sub/c.txt"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "target")
+			for _, f := range files {
+				path := filepath.Join(dir, filepath.FromSlash(f))
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			d := New(
+				config.WithReporter(reporter.NoopReporter{}),
+				config.WithIncludeOnly(tt.includes...),
+				config.WithExcludes(tt.excludes),
+			)
+			if _, err := d.Delete(dir); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if got := remaining(t, dir); !slices.Equal(got, tt.want) {
+				t.Errorf("left %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package deleter
+
+import (
+	"io/fs"
+	"os"
+
+	"github.com/yourusername/rmrf/internal/trash"
+)
+
+// fileSystem is the set of mutating calls the deleter makes. Every change
+// to the tree goes through it. A dry run makes none of these calls, and
+// swaps in an implementation that cannot touch anything besides, should
+// one slip through. The *At variants address name relative to
+// an open directory rather than by full path.
+type fileSystem interface {
+	Chmod(name string, mode os.FileMode) error
+	ChmodAt(dir *os.File, name string, mode os.FileMode) error
+	Remove(name string) error
+	RemoveAt(dir *os.File, name string, isDir bool) error
+	Trash(name string) (trash.Item, error)
+	ClearAttrs(f *os.File) error
+	DeferRemove(name string) error
+	Shred(name string, passes int) error
+	RemoveSubvolume(dir *os.File, name string) error
+	// Rename fails with an error matching fs.ErrExist rather than
+	// replace an existing newname.
+	Rename(oldname, newname string) error
+}
+
+// osFileSystem performs real filesystem operations.
+type osFileSystem struct{}
+
+func (osFileSystem) Chmod(name string, mode os.FileMode) error { return os.Chmod(name, mode) }
+func (osFileSystem) Remove(name string) error                  { return os.Remove(name) }
+func (osFileSystem) ChmodAt(dir *os.File, name string, mode os.FileMode) error {
+	return chmodAt(dir, name, mode)
+}
+func (osFileSystem) RemoveAt(dir *os.File, name string, isDir bool) error {
+	return removeAt(dir, name, isDir)
+}
+func (osFileSystem) Rename(oldname, newname string) error  { return renameExcl(oldname, newname) }
+func (osFileSystem) Trash(name string) (trash.Item, error) { return trash.Move(name) }
+func (osFileSystem) ClearAttrs(f *os.File) error           { return clearAttrs(f) }
+func (osFileSystem) DeferRemove(name string) error         { return deferRemove(name) }
+func (osFileSystem) RemoveSubvolume(dir *os.File, name string) error {
+	return destroySubvolume(dir, name)
+}
+func (osFileSystem) Shred(name string, passes int) error {
+	open := func() (*os.File, error) { return os.OpenFile(name, os.O_WRONLY, 0) }
+	lstat := func() (os.FileInfo, error) { return os.Lstat(name) }
+	return shredPath(name, open, lstat, passes)
+}
+
+// uringFileSystem removes entries relative to open directories through an
+// io_uring, see WithEngine.
+type uringFileSystem struct {
+	osFileSystem
+	ring *uring
+}
+
+func (f uringFileSystem) RemoveAt(dir *os.File, name string, isDir bool) error {
+	return f.ring.unlinkAt(dir, name, isDir)
+}
+
+// renameExclRacy renames oldname to newname if newname does not exist
+// yet. Something created at newname in between may still be replaced.
+func renameExclRacy(oldname, newname string) error {
+	if _, err := os.Lstat(newname); !os.IsNotExist(err) {
+		if err == nil {
+			err = fs.ErrExist
+		}
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: err}
+	}
+	return os.Rename(oldname, newname)
+}
+
+// dryRunFileSystem reports success for every call without side effects.
+type dryRunFileSystem struct{}
+
+func (dryRunFileSystem) Chmod(string, os.FileMode) error             { return nil }
+func (dryRunFileSystem) Remove(string) error                         { return nil }
+func (dryRunFileSystem) ChmodAt(*os.File, string, os.FileMode) error { return nil }
+func (dryRunFileSystem) RemoveAt(*os.File, string, bool) error       { return nil }
+func (dryRunFileSystem) ClearAttrs(*os.File) error                   { return nil }
+func (dryRunFileSystem) DeferRemove(string) error                    { return nil }
+func (dryRunFileSystem) Shred(string, int) error                     { return nil }
+func (dryRunFileSystem) RemoveSubvolume(*os.File, string) error      { return nil }
+func (dryRunFileSystem) Rename(string, string) error                 { return nil }
+func (dryRunFileSystem) Trash(name string) (trash.Item, error) {
+	return trash.Item{Original: name}, nil
+}
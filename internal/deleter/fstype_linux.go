@@ -0,0 +1,74 @@
+package deleter
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// Filesystem magic numbers from statfs(2).
+var fsMagic = map[int64]string{
+	0x01021994: "tmpfs",
+	0xEF53:     "ext4",
+	0x58465342: "xfs",
+	0x9123683E: "btrfs",
+	0x794C7630: "overlayfs",
+	0x6969:     "nfs",
+	0xFF534D42: "cifs",
+	0xFE534D42: "smb2",
+	0x65735546: "fuse",
+	0x2FC12FC1: "zfs",
+	0xF2F52010: "f2fs",
+	0xCA451A4E: "bcachefs",
+}
+
+// fsType names the filesystem holding path, if it is one we know.
+func fsType(path string) (string, bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return "", false
+	}
+	name, ok := fsMagic[int64(st.Type)]
+	if name == "fuse" {
+		name = fuseType(path)
+	}
+	return name, ok
+}
+
+// fuseType names the FUSE filesystem holding path by its subtype in
+// /proc/self/mounts, "sshfs" for "fuse.sshfs", say, or "fuse" if unknown.
+// The mount that contains path with the longest mount point is the one.
+func fuseType(path string) string {
+	f, err := os.Open("/proc/self/mounts")
+	if err != nil {
+		return "fuse"
+	}
+	defer f.Close()
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return "fuse"
+	}
+	name, longest := "fuse", -1
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		mnt := unescapeMount(fields[1])
+		if isWithin(mnt, resolved) && len(mnt) > longest {
+			name, longest = "fuse", len(mnt)
+			if sub, ok := strings.CutPrefix(fields[2], "fuse."); ok {
+				name = sub
+			}
+		}
+	}
+	return name
+}
+
+// unescapeMount undoes the octal escapes of spaces, tabs, newlines and
+// backslashes in a /proc/self/mounts field.
+func unescapeMount(field string) string {
+	return strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`).Replace(field)
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package deleter
+
+func fsType(path string) (string, bool) {
+	return "", false
+}
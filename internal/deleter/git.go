@@ -0,0 +1,56 @@
+package deleter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/rmrf/internal/config"
+)
+
+// gitTargets replaces each of paths, directories in git work trees, with
+// what git clean would remove below it under WithGitClean: the untracked,
+// or only the ignored, files, and whole directories where everything in
+// them is. Git itself applies .gitignore, .git/info/exclude and the global
+// excludes file.
+func (d *Deleter) gitTargets(ctx context.Context, paths []string) ([]string, error) {
+	var targets []string
+	for _, path := range paths {
+		args := []string{"-C", path, "ls-files", "-z", "--others", "--directory"}
+		if d.config.GitClean == config.GitIgnored {
+			args = append(args, "--ignored", "--exclude-standard")
+		}
+		out, err := exec.CommandContext(ctx, "git", args...).Output()
+		if err != nil {
+			var exit *exec.ExitError
+			if errors.As(err, &exit) && len(exit.Stderr) > 0 {
+				err = errors.New(string(bytes.TrimSpace(exit.Stderr)))
+			}
+			return nil, fmt.Errorf("%s: listing files with git: %w", path, err)
+		}
+
+		for _, name := range strings.Split(string(out), "\x00") {
+			if name == "" {
+				continue
+			}
+			target := filepath.Join(path, filepath.FromSlash(name))
+			if strings.HasSuffix(name, "/") && isRepository(target) {
+				d.stats.AddWarning(fmt.Sprintf("skipping %s: a git repository of its own", target))
+				continue
+			}
+			targets = append(targets, target)
+		}
+	}
+	return targets, nil
+}
+
+// isRepository reports whether dir is the top of a git work tree.
+func isRepository(dir string) bool {
+	_, err := os.Lstat(filepath.Join(dir, ".git"))
+	return err == nil
+}
@@ -0,0 +1,88 @@
+package deleter
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// hasMeta reports whether pattern contains glob metacharacters.
+func hasMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// glob is filepath.Glob with one addition: a path segment consisting of
+// "**" matches zero or more directories. Symlinked directories are not
+// descended into while expanding "**". Results are sorted.
+func glob(pattern string) ([]string, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	pattern = filepath.Clean(pattern)
+	base := "."
+	if filepath.IsAbs(pattern) {
+		base = filepath.VolumeName(pattern) + string(filepath.Separator)
+		pattern = strings.TrimPrefix(pattern[len(filepath.VolumeName(pattern)):], string(filepath.Separator))
+	}
+
+	var matches []string
+	seen := make(map[string]bool)
+	globSegments(base, strings.Split(pattern, string(filepath.Separator)), func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			matches = append(matches, path)
+		}
+	})
+	sort.Strings(matches)
+	return matches, nil
+}
+
+func globSegments(base string, segs []string, emit func(string)) {
+	if len(segs) == 0 {
+		emit(base)
+		return
+	}
+	seg, rest := segs[0], segs[1:]
+
+	if seg == "**" {
+		globSegments(base, rest, emit)
+		entries, err := os.ReadDir(base)
+		if err != nil {
+			return
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				globSegments(join(base, entry.Name()), segs, emit)
+			}
+		}
+		return
+	}
+
+	if !hasMeta(seg) {
+		path := join(base, seg)
+		if _, err := os.Lstat(path); err == nil {
+			globSegments(path, rest, emit)
+		}
+		return
+	}
+
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if ok, _ := filepath.Match(seg, entry.Name()); ok {
+			globSegments(join(base, entry.Name()), rest, emit)
+		}
+	}
+}
+
+// join is filepath.Join that keeps relative results free of a leading "./".
+func join(base, name string) string {
+	if base == "." {
+		return name
+	}
+	return filepath.Join(base, name)
+}
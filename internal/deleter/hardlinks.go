@@ -0,0 +1,57 @@
+package deleter
+
+import "os"
+
+// hardlink tracks the removal of a file with several hard links.
+type hardlink struct {
+	links   uint64 // most links seen in an lstat
+	removed uint64
+	counted bool
+}
+
+// addFreed adds the size of a removed file, given its lstat from before,
+// to the bytes freed. Removing one of several hard links frees nothing,
+// so such a file counts only once as many links have been removed as it
+// had; if some of them are outside the targets, it never does.
+//
+// Link counts drop as links are removed, so the count taken is the
+// highest seen, and a file seen with one link counts at once unless
+// others were seen before.
+func (d *Deleter) addFreed(info os.FileInfo) {
+	if !d.lastLink(info) {
+		return
+	}
+	d.stats.AddBytes(allocatedBytes(info), info.Size())
+}
+
+// lastLink records the removal of the file with the lstat info and
+// reports whether its space is now free, see addFreed.
+func (d *Deleter) lastLink(info os.FileInfo) bool {
+	n := linkCount(info)
+	dev, ino, ok := fileID(info)
+	if !ok {
+		return true
+	}
+	key := fileKey{dev, ino}
+
+	d.linksMu.Lock()
+	defer d.linksMu.Unlock()
+	h := d.links[key]
+	if h == nil {
+		if n <= 1 {
+			return true
+		}
+		if d.links == nil {
+			d.links = make(map[fileKey]*hardlink)
+		}
+		h = &hardlink{}
+		d.links[key] = h
+	}
+	h.links = max(h.links, n)
+	h.removed++
+	if h.counted || h.removed < h.links {
+		return false
+	}
+	h.counted = true
+	return true
+}
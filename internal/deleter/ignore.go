@@ -0,0 +1,147 @@
+package deleter
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreFileName is the file whose gitignore-style patterns protect paths
+// in its directory from deletion, see WithNestedIgnoreFiles.
+const ignoreFileName = ".rmrfignore"
+
+// ignoreRule is one pattern of an ignore file.
+type ignoreRule struct {
+	parts   []string // components to match, "**" matching any number
+	dirOnly bool     // the pattern ended in "/"
+	negate  bool     // the pattern started with "!"
+}
+
+// ignoreFile holds the rules of the ignore file in dir.
+type ignoreFile struct {
+	dir   string
+	rules []ignoreRule
+}
+
+// readIgnoreFile parses the ignore file in dir with gitignore syntax:
+// blank lines and "#" comments are skipped, "!" re-includes, a trailing
+// "/" matches directories only, and a pattern with a "/" before its end
+// is anchored to dir while one without matches at any depth. It returns
+// nil if there is no ignore file.
+func readIgnoreFile(dir string) (*ignoreFile, error) {
+	f, err := os.Open(filepath.Join(dir, ignoreFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ignore := &ignoreFile{dir: dir}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var rule ignoreRule
+		line, rule.negate = strings.CutPrefix(line, "!")
+		line = strings.TrimPrefix(line, `\`)
+		line, rule.dirOnly = strings.CutSuffix(line, "/")
+		if !strings.Contains(line, "/") {
+			line = "**/" + line
+		}
+		rule.parts = strings.Split(strings.TrimPrefix(line, "/"), "/")
+		if err := validatePatterns(rule.parts); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Join(dir, ignoreFileName), err)
+		}
+		ignore.rules = append(ignore.rules, rule)
+	}
+	return ignore, scanner.Err()
+}
+
+// matches reports whether path, inside the ignore file's directory, is
+// protected by it: the last rule matching path decides.
+func (f *ignoreFile) matches(path string, isDir bool) bool {
+	rel, err := filepath.Rel(f.dir, path)
+	if err != nil {
+		return false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+
+	protected := false
+	for _, rule := range f.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if matchSegments(rule.parts, parts) {
+			protected = !rule.negate
+		}
+	}
+	return protected
+}
+
+// matchSegments reports whether parts match the pattern components, "**"
+// standing for any number of parts.
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	ok, _ := filepath.Match(pattern[0], parts[0])
+	return ok && matchSegments(pattern[1:], parts[1:])
+}
+
+// loadIgnores returns the ignore files in effect in t's directory: its
+// parent's, plus its own if it is a target or WithNestedIgnoreFiles is set.
+func (d *Deleter) loadIgnores(r *run, t *dirTask) []*ignoreFile {
+	var ignores []*ignoreFile
+	if t.parent != nil {
+		ignores = t.parent.ignores
+		if !d.config.NestedIgnoreFiles {
+			return ignores
+		}
+	}
+	f, err := readIgnoreFile(t.path)
+	if err != nil {
+		d.fail(r, err)
+		return ignores
+	}
+	if f == nil {
+		return ignores
+	}
+	return append(ignores[:len(ignores):len(ignores)], f)
+}
+
+// ignored reports whether an ignore file protects the entry at path in
+// t's directory. An ignore file that is honored is kept too, so what it
+// protects stays protected for the next run.
+func ignored(t *dirTask, path string, entry os.DirEntry) bool {
+	if len(t.ignores) == 0 {
+		return false
+	}
+	if entry.Name() == ignoreFileName && t.ignores[len(t.ignores)-1].dir == t.path {
+		return true
+	}
+	for _, f := range t.ignores {
+		if f.matches(path, entry.IsDir()) {
+			return true
+		}
+	}
+	return false
+}
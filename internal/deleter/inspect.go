@@ -0,0 +1,125 @@
+package deleter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// inspectListMax bounds how many paths an InspectReport lists per kind;
+// the counts go on past it.
+const inspectListMax = 20
+
+// InspectReport describes what deleting a target would involve.
+type InspectReport struct {
+	Path     string
+	Resolved string
+	Refused  error // why a deletion would refuse the target, if it would
+
+	Files, Dirs, Symlinks int
+	Bytes                 int64
+
+	MountPoints []string // filesystems mounted inside the target
+
+	OtherOwners int      // entries owned by other users than the effective one
+	OtherOwned  []string // the first inspectListMax of them
+	Setuid      int      // setuid or setgid files
+	SetuidPaths []string // the first inspectListMax of them
+	Newest      time.Time
+	NewestPath  string
+	Unreadable  int // directories that could not be listed
+}
+
+// Inspect walks path the way a deletion would, without following
+// symlinks, a symlink target included, and without changing anything, and
+// reports what it found. Mounts inside the tree are listed and walked
+// into, as deleting would unless WithOneFileSystem is set.
+func (d *Deleter) Inspect(ctx context.Context, path string) (InspectReport, error) {
+	var err error
+	report := InspectReport{Path: path, Refused: d.validatePath(path)}
+	if errors.Is(report.Refused, ErrNotExist) {
+		return report, report.Refused
+	}
+	if report.Resolved, err = resolvePath(path); err != nil {
+		return report, err
+	}
+	canonical, err := canonicalPath(path)
+	if err != nil {
+		return report, err
+	}
+	if err := inspectTree(ctx, canonical, &report); err != nil {
+		return report, fmt.Errorf("%w: %w", ErrCancelled, err)
+	}
+	return report, nil
+}
+
+// inspectTree walks root, a canonical path, adding what it finds to
+// report. It only fails if ctx is done.
+func inspectTree(ctx context.Context, root string, report *InspectReport) error {
+	uid := os.Geteuid()
+	return filepath.WalkDir(root, func(p string, entry fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			report.Unreadable++
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+
+		switch {
+		case entry.IsDir():
+			report.Dirs++
+			if p != root && isMountPoint(p) {
+				report.MountPoints = append(report.MountPoints, p)
+			}
+		case entry.Type()&fs.ModeSymlink != 0:
+			report.Symlinks++
+		default:
+			report.Files++
+			if entry.Type().IsRegular() {
+				report.Bytes += info.Size()
+			}
+		}
+
+		noteSensitive(report, uid, p, info)
+		if info.ModTime().After(report.Newest) {
+			report.Newest, report.NewestPath = info.ModTime(), p
+		}
+		return nil
+	})
+}
+
+// noteSensitive counts the entry at path in report if it is owned by
+// another user than uid, an effective user ID, or is a setuid or setgid
+// file, and reports whether it was either.
+func noteSensitive(report *InspectReport, uid int, path string, info fs.FileInfo) bool {
+	sensitive := false
+	if owner, _, ok := fileOwner(info); ok && uid >= 0 && owner != uid {
+		report.OtherOwners++
+		report.OtherOwned = appendCapped(report.OtherOwned, path)
+		sensitive = true
+	}
+	if info.Mode().IsRegular() && info.Mode()&(fs.ModeSetuid|fs.ModeSetgid) != 0 {
+		report.Setuid++
+		report.SetuidPaths = appendCapped(report.SetuidPaths, path)
+		sensitive = true
+	}
+	return sensitive
+}
+
+// appendCapped appends path to list unless it already holds
+// inspectListMax paths.
+func appendCapped(list []string, path string) []string {
+	if len(list) >= inspectListMax {
+		return list
+	}
+	return append(list, path)
+}
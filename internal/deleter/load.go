@@ -0,0 +1,77 @@
+package deleter
+
+import "time"
+
+// loadSampleInterval is how often the adaptive load controller re-reads the
+// load average. The 1-minute average moves slowly, so one slot per sample
+// is enough to follow it.
+const loadSampleInterval = time.Second
+
+// loadRatio returns how far the system is from the configured limits, the
+// load average against AdaptiveLoad and the IO pressure against
+// MaxPressure: above 1 means over one of them. ok is false if neither can
+// be read on this system.
+func (d *Deleter) loadRatio() (ratio float64, ok bool) {
+	if d.config.AdaptiveLoad > 0 {
+		if load, known := loadAverage(); known {
+			ratio, ok = load/d.config.AdaptiveLoad, true
+		}
+	}
+	if d.config.MaxPressure > 0 {
+		if pressure, known := ioPressure(); known {
+			ratio, ok = max(ratio, pressure/d.config.MaxPressure), true
+		}
+	}
+	return ratio, ok
+}
+
+// throttleOnLoad holds semaphore slots while the system load is above the
+// configured target, leaving fewer slots for the pool's workers, and
+// hands them back as the load drops. It waits for a busy worker to finish
+// its task to take its slot. Holding the last one pauses the run until
+// the load drops below the target; Stats.LoadPaused adds up how long. It
+// releases everything it holds when done is closed.
+func (d *Deleter) throttleOnLoad(sem chan struct{}, done <-chan struct{}) {
+	held := 0
+	var paused time.Time
+	resume := func() {
+		if !paused.IsZero() {
+			d.stats.LoadPaused += time.Since(paused)
+			paused = time.Time{}
+		}
+	}
+	defer func() {
+		resume()
+		for ; held > 0; held-- {
+			d.releaseSlot(sem)
+		}
+	}()
+
+	ticker := time.NewTicker(loadSampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		ratio, ok := d.loadRatio()
+		if !ok {
+			return
+		}
+
+		switch {
+		case ratio > 1 && d.holdSlot(sem, done, true):
+			held++
+			if int(d.held.Load()) == cap(sem) {
+				paused = time.Now()
+			}
+		case ratio < 1 && held > 0:
+			resume()
+			d.releaseSlot(sem)
+			held--
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package deleter
+
+import (
+	"encoding/binary"
+	"syscall"
+)
+
+// loadAverage returns the 1-minute load average from the vm.loadavg
+// sysctl, a struct loadavg: three fixed-point averages and their scale.
+func loadAverage() (float64, bool) {
+	raw, err := syscall.Sysctl("vm.loadavg")
+	if err != nil {
+		return 0, false
+	}
+	// Sysctl drops a trailing zero byte, which the scale usually ends in.
+	buf := make([]byte, 24)
+	if copy(buf, raw) < 16 {
+		return 0, false
+	}
+	scale := binary.LittleEndian.Uint64(buf[16:])
+	if scale == 0 {
+		return 0, false
+	}
+	return float64(binary.LittleEndian.Uint32(buf)) / float64(scale), true
+}
+
+func ioPressure() (float64, bool) {
+	return 0, false
+}
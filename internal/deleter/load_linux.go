@@ -0,0 +1,47 @@
+package deleter
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// loadAverage returns the 1-minute load average from /proc/loadavg.
+func loadAverage() (float64, bool) {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0, false
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, false
+	}
+	load, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, false
+	}
+	return load, true
+}
+
+// ioPressure returns the share of the last 10 seconds, in percent, in
+// which some task was stalled on IO, from /proc/pressure/io. It needs
+// Linux 4.20 or later with PSI enabled.
+func ioPressure() (float64, bool) {
+	data, err := os.ReadFile("/proc/pressure/io")
+	if err != nil {
+		return 0, false
+	}
+	for line := range strings.Lines(string(data)) {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "some" {
+			continue
+		}
+		avg, ok := strings.CutPrefix(fields[1], "avg10=")
+		if !ok {
+			return 0, false
+		}
+		pressure, err := strconv.ParseFloat(avg, 64)
+		return pressure, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package deleter
+
+func loadAverage() (float64, bool) {
+	return 0, false
+}
+
+func ioPressure() (float64, bool) {
+	return 0, false
+}
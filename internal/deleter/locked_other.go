@@ -0,0 +1,15 @@
+//go:build !windows
+
+package deleter
+
+import "errors"
+
+// Only Windows keeps files from being deleted while they are open.
+
+func isLocked(err error) bool {
+	return false
+}
+
+func deferRemove(path string) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,44 @@
+//go:build windows
+
+package deleter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	errorSharingViolation = syscall.Errno(32) // ERROR_SHARING_VIOLATION
+	errorLockViolation    = syscall.Errno(33) // ERROR_LOCK_VIOLATION
+
+	movefileDelayUntilReboot = 0x4
+)
+
+var procMoveFileExW = syscall.NewLazyDLL("kernel32.dll").NewProc("MoveFileExW")
+
+// isLocked reports whether err means another process has the file open
+// in a way that keeps it from being deleted.
+func isLocked(err error) bool {
+	return errors.Is(err, errorSharingViolation) || errors.Is(err, errorLockViolation)
+}
+
+// deferRemove asks Windows to delete path at the next reboot, which takes
+// administrator rights. Entries are deleted in the order they were
+// scheduled, so a directory scheduled after its contents goes too.
+func deferRemove(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	p, err := syscall.UTF16PtrFromString(longPath(abs))
+	if err != nil {
+		return err
+	}
+	if ok, _, err := procMoveFileExW.Call(uintptr(unsafe.Pointer(p)), 0, movefileDelayUntilReboot); ok == 0 {
+		return &os.PathError{Op: "schedule removal", Path: path, Err: err}
+	}
+	return nil
+}
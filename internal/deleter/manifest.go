@@ -0,0 +1,66 @@
+package deleter
+
+import (
+	"errors"
+	"os"
+
+	"github.com/yourusername/rmrf/internal/reporter"
+)
+
+// entryType names the kind of entry info describes, for records of what
+// a run did.
+func entryType(info os.FileInfo) string {
+	switch {
+	case info.IsDir():
+		return "dir"
+	case info.Mode()&os.ModeSymlink != 0:
+		return "symlink"
+	case !info.Mode().IsRegular():
+		return "special"
+	}
+	return "file"
+}
+
+// recordManifest passes what became of the entry at path to the manifest
+// callback, if any. info is its lstat, taken here if not given.
+func (d *Deleter) recordManifest(path string, info os.FileInfo, status, detail string) {
+	if d.config.Manifest == nil {
+		return
+	}
+	if info == nil {
+		info, _ = os.Lstat(path)
+	}
+	row := reporter.ManifestRow{Path: path, Status: status, Detail: detail}
+	if info != nil {
+		row.Type, row.Size, row.Mtime = entryType(info), info.Size(), info.ModTime()
+		if info.IsDir() {
+			row.Size = 0
+		}
+	}
+	d.config.Manifest(row)
+}
+
+// manifestError records a failure in the manifest under the path it
+// names. Errors naming no path, which are not about an entry, are left
+// out.
+func (d *Deleter) manifestError(err error) {
+	if d.config.Manifest == nil {
+		return
+	}
+	if path, ok := errorPath(err); ok {
+		d.recordManifest(path, nil, reporter.StatusError, err.Error())
+	}
+}
+
+// errorPath returns the path err is about, if it names one.
+func errorPath(err error) (string, bool) {
+	var pathErr *os.PathError
+	var linkErr *os.LinkError
+	switch {
+	case errors.As(err, &pathErr):
+		return pathErr.Path, true
+	case errors.As(err, &linkErr):
+		return linkErr.Old, true
+	}
+	return "", false
+}
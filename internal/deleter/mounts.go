@@ -0,0 +1,45 @@
+package deleter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// onOtherDevice reports whether entry, a directory inside the target, is
+// on a different filesystem than the target and must be left alone under
+// WithOneFileSystem. Only directories are checked: anything mounted inside
+// the tree is reached through one.
+func (d *Deleter) onOtherDevice(r *run, path string, entry os.DirEntry) bool {
+	if !d.config.OneFileSystem || !r.devKnown || !entry.IsDir() {
+		return false
+	}
+	info, err := entry.Info()
+	if err != nil {
+		return false
+	}
+	dev, _, ok := fileID(info)
+	if !ok || dev == r.dev {
+		return false
+	}
+	d.stats.AddOtherDevice(fmt.Sprintf("skipping %s: on a different filesystem", path))
+	d.emitSkipped(path, "other filesystem")
+	return true
+}
+
+// isMountPoint reports whether path, a clean absolute path, is where a
+// filesystem is mounted: its device differs from its parent's. Where
+// device numbers are unavailable it reports false.
+func isMountPoint(path string) bool {
+	info, err := os.Lstat(path)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+	parent, err := os.Lstat(filepath.Dir(path))
+	if err != nil {
+		return false
+	}
+	dev, _, ok := fileID(info)
+	parentDev, _, parentOK := fileID(parent)
+	return ok && parentOK && dev != parentDev
+}
@@ -0,0 +1,62 @@
+package deleter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// sillyRenamePrefix starts the names an NFS client gives files removed
+// while some process still has them open, "silly renames": they stay until
+// the last one is closed, and keep their directory from being removed
+// meanwhile.
+const sillyRenamePrefix = ".nfs"
+
+// heldOpen rewrites err, the failure to remove the entry at path, as
+// ErrNFSHeldOpen if NFS silly renames are why: a busy .nfs file, or a
+// directory not empty only because of them. transient counts it, so the
+// retry rounds wait for the files to be closed before reporting it.
+func heldOpen(path string, dir bool, err error) error {
+	switch {
+	case err == nil:
+	case !dir && strings.HasPrefix(filepath.Base(path), sillyRenamePrefix) && errors.Is(err, syscall.EBUSY):
+		return fmt.Errorf("%w: %s", ErrNFSHeldOpen, path)
+	case dir && (errors.Is(err, syscall.ENOTEMPTY) || errors.Is(err, syscall.EEXIST)):
+		if names := sillyRenamed(path); len(names) > 3 {
+			return fmt.Errorf("%w: %s holds %s and %d more", ErrNFSHeldOpen, path, strings.Join(names[:3], ", "), len(names)-3)
+		} else if len(names) > 0 {
+			return fmt.Errorf("%w: %s holds %s", ErrNFSHeldOpen, path, strings.Join(names, ", "))
+		}
+	}
+	return err
+}
+
+// sillyRenamed returns the entries of the directory at path if they are
+// all NFS silly renames, and nil otherwise.
+func sillyRenamed(path string) []string {
+	dir, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer dir.Close()
+	var silly []string
+	for {
+		names, err := dir.Readdirnames(readDirBatch)
+		for _, name := range names {
+			if !strings.HasPrefix(name, sillyRenamePrefix) {
+				return nil
+			}
+			silly = append(silly, name)
+		}
+		if err == io.EOF {
+			return silly
+		}
+		if err != nil {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package deleter
+
+import (
+	"os"
+
+	"github.com/yourusername/rmrf/internal/reporter"
+)
+
+// planning reports whether deleted entries need to be described to the
+// plan callback, so callers can skip the lstat otherwise.
+func (d *Deleter) planning() bool {
+	return d.config.Plan != nil
+}
+
+// recordPlan passes a deleted entry to the plan callback. Calls are
+// serialized so the callback may write to a shared stream.
+func (d *Deleter) recordPlan(path string, info os.FileInfo) {
+	if d.config.Plan == nil || info == nil {
+		return
+	}
+
+	entry := reporter.PlanEntry{
+		Path:  path,
+		Type:  "file",
+		Size:  info.Size(),
+		Mtime: info.ModTime(),
+	}
+	if info.IsDir() {
+		entry.Type = "dir"
+		entry.Size = 0
+	}
+	entry.Dev, entry.Inode, _ = fileID(info)
+
+	d.planMu.Lock()
+	defer d.planMu.Unlock()
+	d.config.Plan(entry)
+}
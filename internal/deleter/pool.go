@@ -0,0 +1,94 @@
+package deleter
+
+import "sync"
+
+// pool runs tasks on a fixed set of workers. Tasks may submit more tasks;
+// the queue is worked last-in first-out, which goes depth first, and
+// trySubmit bounds it on wide trees. Before running a task a worker takes
+// a slot in sem, which is how throttleOnLoad and startAdaptive lower the
+// effective concurrency below the number of workers.
+//
+// Every submitted task runs, even after the run is cancelled; tasks are
+// expected to check for that themselves and return at once.
+type pool struct {
+	sem   chan struct{}
+	mu    sync.Mutex
+	cond  *sync.Cond
+	queue []func()
+	done  bool
+
+	pending sync.WaitGroup // submitted tasks not yet run
+	workers sync.WaitGroup
+}
+
+func newPool(workers int, sem chan struct{}) *pool {
+	p := &pool{sem: sem}
+	p.cond = sync.NewCond(&p.mu)
+	p.workers.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// submit queues task.
+func (p *pool) submit(task func()) {
+	p.pending.Add(1)
+	p.mu.Lock()
+	p.queue = append(p.queue, task)
+	p.mu.Unlock()
+	p.cond.Signal()
+}
+
+// maxQueued is how many tasks may wait in the queue before trySubmit
+// refuses more. A variable so tests can lower it.
+var maxQueued = 4096
+
+// trySubmit queues task unless maxQueued tasks are waiting already, and
+// reports whether it did.
+func (p *pool) trySubmit(task func()) bool {
+	p.mu.Lock()
+	if len(p.queue) >= maxQueued {
+		p.mu.Unlock()
+		return false
+	}
+	p.pending.Add(1)
+	p.queue = append(p.queue, task)
+	p.mu.Unlock()
+	p.cond.Signal()
+	return true
+}
+
+// wait blocks until every submitted task, including ones submitted by
+// other tasks, has run, then stops the workers.
+func (p *pool) wait() {
+	p.pending.Wait()
+	p.mu.Lock()
+	p.done = true
+	p.mu.Unlock()
+	p.cond.Broadcast()
+	p.workers.Wait()
+}
+
+func (p *pool) work() {
+	defer p.workers.Done()
+	for {
+		p.mu.Lock()
+		for len(p.queue) == 0 && !p.done {
+			p.cond.Wait()
+		}
+		if len(p.queue) == 0 {
+			p.mu.Unlock()
+			return
+		}
+		task := p.queue[len(p.queue)-1]
+		p.queue[len(p.queue)-1] = nil
+		p.queue = p.queue[:len(p.queue)-1]
+		p.mu.Unlock()
+
+		p.sem <- struct{}{}
+		task()
+		<-p.sem
+		p.pending.Done()
+	}
+}
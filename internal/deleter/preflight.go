@@ -0,0 +1,101 @@
+package deleter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// preflightSampleDirs bounds how many directories a preflight scan looks
+// at, so the check stays quick on huge trees.
+const preflightSampleDirs = 256
+
+// PreflightReport summarizes a sampled permission scan.
+type PreflightReport struct {
+	Sampled int // directories inspected
+	Denied  int // directories we likely cannot empty
+}
+
+// DeniedFraction is the share of sampled directories predicted to fail.
+func (p PreflightReport) DeniedFraction() float64 {
+	if p.Sampled == 0 {
+		return 0
+	}
+	return float64(p.Denied) / float64(p.Sampled)
+}
+
+func (p PreflightReport) String() string {
+	return fmt.Sprintf("you may not have permission to delete %.0f%% of this tree (%d of %d sampled directories)",
+		p.DeniedFraction()*100, p.Denied, p.Sampled)
+}
+
+// Preflight walks a bounded, breadth-first sample of the directories under
+// paths and predicts how many of them the current user cannot empty. Glob
+// arguments are expanded as in DeleteMany; ones matching nothing are
+// ignored here. It never modifies anything.
+func (d *Deleter) Preflight(paths ...string) (PreflightReport, error) {
+	var report PreflightReport
+
+	var queue []string
+	for _, arg := range paths {
+		matches, err := d.expandTarget(arg)
+		if err != nil {
+			continue // reported by the deletion itself
+		}
+		for _, path := range matches {
+			if info, err := os.Stat(path); err != nil || !info.IsDir() {
+				continue
+			}
+			absPath, err := filepath.Abs(path)
+			if err != nil {
+				return report, err
+			}
+			queue = append(queue, absPath)
+		}
+	}
+
+	for len(queue) > 0 && report.Sampled < preflightSampleDirs {
+		dir := queue[0]
+		queue = queue[1:]
+
+		report.Sampled++
+		if !canEmptyDir(dir) {
+			report.Denied++
+			continue
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			report.Denied++
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				queue = append(queue, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// preflight runs the configured permission check before a deletion,
+// failing with ErrPreflightDenied when the check is strict.
+func (d *Deleter) preflight(path string) error {
+	if !d.config.PreflightCheck {
+		return nil
+	}
+
+	report, err := d.Preflight(path)
+	if err != nil {
+		return err
+	}
+	if report.Denied == 0 {
+		return nil
+	}
+	if d.config.PreflightAbort {
+		return fmt.Errorf("%w: %s", ErrPreflightDenied, report)
+	}
+	d.stats.AddWarning(report.String())
+	return nil
+}
@@ -0,0 +1,7 @@
+//go:build !linux && !darwin
+
+package deleter
+
+func canEmptyDir(dir string) bool {
+	return true
+}
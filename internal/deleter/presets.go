@@ -0,0 +1,82 @@
+package deleter
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/yourusername/rmrf/internal/config"
+)
+
+// presetTargets replaces each of paths with the directories in it that the
+// configured presets recognize, see WithPresets.
+func (d *Deleter) presetTargets(ctx context.Context, paths []string) ([]string, error) {
+	var presets []config.Preset
+	for _, name := range d.config.Presets {
+		p, ok := config.Presets[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown preset %q", name)
+		}
+		presets = append(presets, p...)
+	}
+	return d.findDirs(ctx, paths, func(path, name string) bool {
+		return presetMatch(presets, path, name)
+	})
+}
+
+// presetMatch reports whether one of presets claims the directory name at
+// path.
+func presetMatch(presets []config.Preset, path, name string) bool {
+	for _, p := range presets {
+		for _, dir := range p.Dirs {
+			if dir != name {
+				continue
+			}
+			if p.Marker == "" {
+				return true
+			}
+			if _, err := os.Lstat(filepath.Join(filepath.Dir(path), p.Marker)); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findDirs walks each of paths, without following symlinks or entering
+// .git directories, and returns the directories, the paths themselves
+// included, that match. Matched directories are not walked any further.
+func (d *Deleter) findDirs(ctx context.Context, paths []string, match func(path, name string) bool) ([]string, error) {
+	var found []string
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				if path == root {
+					return err
+				}
+				d.stats.AddWarning(fmt.Sprintf("not searched: %v", err))
+				return nil
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if !entry.IsDir() {
+				return nil
+			}
+			if entry.Name() == ".git" && path != root {
+				return filepath.SkipDir
+			}
+			if match(path, entry.Name()) {
+				found = append(found, path)
+				return filepath.SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,81 @@
+package deleter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// answer is a reply to an interactive prompt.
+type answer int
+
+const (
+	answerNo answer = iota
+	answerYes
+	answerAll     // yes to this and everything after it
+	answerQuit    // stop the run, keeping everything not yet removed
+	answerSkipDir // keep this entry and the rest of its directory
+)
+
+// approve decides whether path may be removed, prompting in interactive
+// mode. skipDir is set when the rest of path's directory should be kept
+// without further prompts.
+func (d *Deleter) approve(r *run, path string, isDir bool) (ok, skipDir bool) {
+	if !d.config.Interactive || d.config.DryRun || d.yesToAll.Load() {
+		return true, false
+	}
+
+	switch d.ask(path, isDir) {
+	case answerYes, answerAll:
+		return true, false
+	case answerQuit:
+		if r.cancel != nil {
+			r.cancel()
+		}
+		return false, false
+	case answerSkipDir:
+		return false, true
+	}
+	return false, false
+}
+
+// ask prompts about path and returns the answer. Workers share a single
+// terminal, so prompts are serialized, and an "a" or "q" given to one
+// worker answers the prompts other workers were waiting to show.
+// Anything unrecognised, including a read error or EOF, counts as no.
+func (d *Deleter) ask(path string, isDir bool) answer {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch {
+	case d.quitting.Load():
+		return answerQuit
+	case d.yesToAll.Load():
+		return answerYes
+	}
+
+	if isDir {
+		fmt.Fprintf(d.promptOut, "descend into directory %s? [y/n/a/q/d] ", path)
+	} else {
+		fmt.Fprintf(d.promptOut, "remove file %s? [y/n/a/q/d] ", path)
+	}
+
+	reply, err := d.promptIn.ReadString('\n')
+	if err != nil && reply == "" {
+		fmt.Fprintln(d.promptOut)
+		return answerNo
+	}
+
+	switch strings.ToLower(strings.TrimSpace(reply)) {
+	case "y", "yes":
+		return answerYes
+	case "a", "all":
+		d.yesToAll.Store(true)
+		return answerAll
+	case "q", "quit":
+		d.quitting.Store(true)
+		return answerQuit
+	case "d":
+		return answerSkipDir
+	}
+	return answerNo
+}
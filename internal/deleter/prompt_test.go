@@ -0,0 +1,224 @@
+package deleter
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/rmrf/internal/config"
+	"github.com/yourusername/rmrf/internal/reporter"
+)
+
+// promptTree creates a directory holding the single file "a" under a temp
+// directory, so prompts come in a fixed order, and returns it.
+func promptTree(t *testing.T) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "target")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestInteractivePrompt(t *testing.T) {
+	tests := []struct {
+		name    string
+		replies string
+		prompts int
+		want    []string // left below the target, nil if it is gone
+	}{
+		{"yes", "y\ny\n", 2, nil},
+		{"yes in full", "yes\nYES\n", 2, nil},
+		{"all", "a\n", 1, nil},
+		{"no to the target", "n\n", 1, []string{"a"}},
+		{"no to the file", "y\nn\n", 2, []string{"a"}},
+		{"eof at the first prompt", "", 1, []string{"a"}},
+		{"eof after a yes", "y\n", 2, []string{"a"}},
+		{"unrecognised reply", "y\nmaybe\n", 2, []string{"a"}},
+		{"reply without newline", "y\ny", 2, nil},
+		{"quit", "y\nq\n", 2, []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := promptTree(t)
+			var out strings.Builder
+			d := New(
+				config.WithReporter(reporter.NoopReporter{}),
+				config.WithMaxThreads(1),
+				config.WithInteractive(true),
+				config.WithPrompt(strings.NewReader(tt.replies), &out),
+			)
+			d.Delete(dir)
+
+			if got := strings.Count(out.String(), "? [y/n/a/q/d] "); got != tt.prompts {
+				t.Errorf("prompted %d times, want %d:\n%s", got, tt.prompts, out.String())
+			}
+			if tt.want == nil {
+				if _, err := os.Lstat(dir); !os.IsNotExist(err) {
+					t.Errorf("%s still exists: %v", dir, err)
+				}
+				return
+			}
+			if got := remaining(t, dir); !slices.Equal(got, tt.want) {
+				t.Errorf("left %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfirmLargePrompt(t *testing.T) {
+	tests := []struct {
+		name    string
+		replies string
+		want    error
+	}{
+		{"yes", "y\n", nil},
+		{"yes without newline", "yes", nil},
+		{"no", "n\n", ErrNotConfirmed},
+		{"eof", "", ErrNotConfirmed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := promptTree(t)
+			var out strings.Builder
+			d := New(
+				config.WithReporter(reporter.NoopReporter{}),
+				config.WithConfirmLarge(1, 0),
+				config.WithPrompt(strings.NewReader(tt.replies), &out),
+			)
+			_, err := d.Delete(dir)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Delete = %v, want %v", err, tt.want)
+			}
+			if !strings.Contains(out.String(), "proceed? [y/N] ") {
+				t.Errorf("did not prompt:\n%s", out.String())
+			}
+			if _, err := os.Lstat(dir); (tt.want == nil) != os.IsNotExist(err) {
+				t.Errorf("%s: Lstat after Delete = %v", dir, err)
+			}
+		})
+	}
+}
+
+func TestConfirmSensitivePrompt(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("needs root to give a file to another user")
+	}
+	tests := []struct {
+		name    string
+		opt     config.Option // the pre-scan, if any
+		replies string
+		prompts int
+		want    error
+	}{
+		{"yes after -I", config.WithConfirmLarge(1, 0), "y\ny\n", 2, nil},
+		{"no after -I", config.WithConfirmLarge(1, 0), "y\nn\n", 2, ErrNotConfirmed},
+		{"no after an estimate", config.WithEstimate(true), "n\n", 1, ErrNotConfirmed},
+		{"eof after an estimate", config.WithEstimate(true), "", 1, ErrNotConfirmed},
+		{"no pre-scan", config.WithMaxThreads(1), "", 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := promptTree(t)
+			if err := os.Chown(filepath.Join(dir, "a"), 12345, 12345); err != nil {
+				t.Fatal(err)
+			}
+			var out strings.Builder
+			d := New(
+				config.WithReporter(reporter.NoopReporter{}),
+				config.WithConfirmSensitive(true),
+				tt.opt,
+				config.WithPrompt(strings.NewReader(tt.replies), &out),
+			)
+			_, err := d.Delete(dir)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Delete = %v, want %v", err, tt.want)
+			}
+			if got := strings.Count(out.String(), "proceed? [y/N] "); got != tt.prompts {
+				t.Errorf("prompted %d times, want %d:\n%s", got, tt.prompts, out.String())
+			}
+			if tt.prompts > 0 && !strings.Contains(out.String(), filepath.Join(dir, "a")) {
+				t.Errorf("did not list the file of another user:\n%s", out.String())
+			}
+			if _, err := os.Lstat(dir); (tt.want == nil) != os.IsNotExist(err) {
+				t.Errorf("%s: Lstat after Delete = %v", dir, err)
+			}
+		})
+	}
+}
+
+func TestConfirmSensitiveWithoutTerminal(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("needs root to give a file to another user")
+	}
+	dir := promptTree(t)
+	if err := os.Chown(filepath.Join(dir, "a"), 12345, 12345); err != nil {
+		t.Fatal(err)
+	}
+	replies := filepath.Join(t.TempDir(), "replies")
+	if err := os.WriteFile(replies, []byte("y\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	in, err := os.Open(replies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+
+	var out strings.Builder
+	d := New(
+		config.WithReporter(reporter.NoopReporter{}),
+		config.WithConfirmSensitive(true),
+		config.WithEstimate(true),
+		config.WithPrompt(in, &out),
+	)
+	_, err = d.Delete(dir)
+	if !errors.Is(err, ErrNotConfirmed) || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("Delete = %v, want ErrNotConfirmed suggesting --force", err)
+	}
+	if out.Len() > 0 {
+		t.Errorf("prompted without a terminal:\n%s", out.String())
+	}
+	if got := remaining(t, dir); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("left %q, want [a]", got)
+	}
+}
+
+// unreadable fails the test if anything reads from it.
+type unreadable struct{ t *testing.T }
+
+func (r unreadable) Read([]byte) (int, error) {
+	r.t.Error("dry run read from the prompt")
+	return 0, io.EOF
+}
+
+func TestDryRunNeverPrompts(t *testing.T) {
+	dir := promptTree(t)
+	var out strings.Builder
+	d := New(
+		config.WithReporter(reporter.NoopReporter{}),
+		config.WithDryRun(true),
+		config.WithInteractive(true),
+		config.WithConfirmLarge(1, 1),
+		config.WithConfirmByName(1),
+		config.WithConfirmSensitive(true),
+		config.WithPrompt(unreadable{t}, &out),
+	)
+	stats, err := d.Delete(dir)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if out.Len() > 0 {
+		t.Errorf("dry run prompted:\n%s", out.String())
+	}
+	if stats.FilesDeleted != 1 {
+		t.Errorf("dry run counted %d files, want 1", stats.FilesDeleted)
+	}
+}
@@ -0,0 +1,99 @@
+package deleter
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/rmrf/internal/config"
+)
+
+// protectedBy returns the first protected path pattern that forbids
+// deleting path, a clean absolute path. A pattern forbids path if path
+// matches it or is an ancestor of something that could match it, and, for
+// a pattern ending in "/**", if path lies below a match.
+func protectedBy(patterns []string, path string) (string, bool) {
+	parts := splitPath(path)
+	for _, pattern := range patterns {
+		subtree := strings.HasSuffix(pattern, "/**")
+		pp := splitPath(filepath.Clean(strings.TrimSuffix(pattern, "/**")))
+
+		n := len(parts)
+		if n > len(pp) {
+			if !subtree {
+				continue
+			}
+			n = len(pp)
+		}
+		if matchParts(pp[:n], parts[:n]) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// splitPath splits a clean absolute path into its components; "/" has
+// none.
+func splitPath(path string) []string {
+	path = strings.Trim(filepath.ToSlash(path), "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// matchParts reports whether each path component matches the glob in the
+// same position. Malformed globs match nothing.
+func matchParts(patterns, parts []string) bool {
+	for i := range parts {
+		if ok, err := filepath.Match(patterns[i], parts[i]); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// protectedMatch is protectedBy without the ancestors: it returns the
+// first pattern that path matches, or lies below a match of if the
+// pattern ends in "/**". Anything it returns true for is kept whole when
+// met inside a target.
+func protectedMatch(patterns []string, path string) (string, bool) {
+	parts := splitPath(path)
+	for _, pattern := range patterns {
+		subtree := strings.HasSuffix(pattern, "/**")
+		pp := splitPath(filepath.Clean(strings.TrimSuffix(pattern, "/**")))
+
+		if len(parts) < len(pp) || len(parts) > len(pp) && !subtree {
+			continue
+		}
+		if matchParts(pp, parts[:len(pp)]) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// loadAdminProtected reads config.SystemProtectedFile, which is honored
+// whatever the options say. A missing file protects nothing.
+func loadAdminProtected() ([]string, error) {
+	patterns, err := config.ReadPatternFile(config.SystemProtectedFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return patterns, err
+}
+
+// protectedEntry reports whether path, inside r's target, matches a
+// protected path pattern.
+func (d *Deleter) protectedEntry(r *run, path string) bool {
+	if len(d.protected) == 0 {
+		return false
+	}
+	rel, err := filepath.Rel(r.root, path)
+	if err != nil {
+		return false
+	}
+	_, ok := protectedMatch(d.protected, filepath.Join(r.absRoot, rel))
+	return ok
+}
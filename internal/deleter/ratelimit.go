@@ -0,0 +1,81 @@
+package deleter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tokenBucket is a token bucket refilling at rate tokens per second, up to
+// a second's worth. Taking more than it holds runs it into debt, which
+// the taker waits out, so a single large file is paced rather than refused.
+type tokenBucket struct {
+	rate float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64) *tokenBucket {
+	return &tokenBucket{rate: rate, tokens: rate, last: time.Now()}
+}
+
+// take removes n tokens and waits until the bucket is out of debt again,
+// or ctx is done.
+func (b *tokenBucket) take(ctx context.Context, n float64) {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = min(b.tokens+now.Sub(b.last).Seconds()*b.rate, b.rate)
+	b.last = now
+	b.tokens -= n
+	wait := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.mu.Unlock()
+
+	if wait <= 0 {
+		return
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// rateLimiter paces removals, see WithRateLimit. Either bucket may be nil.
+type rateLimiter struct {
+	entries *tokenBucket
+	bytes   *tokenBucket
+}
+
+// newRateLimiter returns the limiter for the configured rates, or nil if
+// there are none.
+func newRateLimiter(entriesPerSec float64, bytesPerSec int64) *rateLimiter {
+	if entriesPerSec <= 0 && bytesPerSec <= 0 {
+		return nil
+	}
+	l := &rateLimiter{}
+	if entriesPerSec > 0 {
+		l.entries = newTokenBucket(entriesPerSec)
+	}
+	if bytesPerSec > 0 {
+		l.bytes = newTokenBucket(float64(bytesPerSec))
+	}
+	return l
+}
+
+// throttle waits, before an entry of size bytes is removed, until the rate
+// limits allow it. A dry run removes nothing and is never slowed.
+func (d *Deleter) throttle(ctx context.Context, size int64) {
+	l := d.limiter
+	if l == nil || d.config.DryRun {
+		return
+	}
+	if l.entries != nil {
+		l.entries.take(ctx, 1)
+	}
+	if l.bytes != nil && size > 0 {
+		l.bytes.take(ctx, float64(size))
+	}
+}
@@ -0,0 +1,47 @@
+package deleter
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// renameNoReplace is RENAME_NOREPLACE for renameat2(2).
+const renameNoReplace = 1
+
+// sysRenameat2 is the number of renameat2(2), which the syscall package
+// only defines on some architectures; zero where it is not known here.
+var sysRenameat2 = map[string]uintptr{
+	"386": 353, "amd64": 316, "arm": 382, "arm64": 276, "loong64": 276,
+	"mips": 4351, "mipsle": 4351, "mips64": 5311, "mips64le": 5311,
+	"ppc64": 357, "ppc64le": 357, "riscv64": 276, "s390x": 347,
+}[runtime.GOARCH]
+
+// renameExcl renames oldname to newname unless newname exists, atomically
+// with renameat2(RENAME_NOREPLACE). Kernels and filesystems without it
+// fall back to checking first, which races.
+func renameExcl(oldname, newname string) error {
+	if sysRenameat2 == 0 {
+		return renameExclRacy(oldname, newname)
+	}
+	oldp, err := syscall.BytePtrFromString(oldname)
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: err}
+	}
+	newp, err := syscall.BytePtrFromString(newname)
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: err}
+	}
+	atFDCWD := -100 // AT_FDCWD
+	_, _, errno := syscall.Syscall6(sysRenameat2,
+		uintptr(atFDCWD), uintptr(unsafe.Pointer(oldp)),
+		uintptr(atFDCWD), uintptr(unsafe.Pointer(newp)), renameNoReplace, 0)
+	switch errno {
+	case 0:
+		return nil
+	case syscall.ENOSYS, syscall.EINVAL:
+		return renameExclRacy(oldname, newname)
+	}
+	return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: errno}
+}
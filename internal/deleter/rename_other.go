@@ -0,0 +1,10 @@
+//go:build !linux
+
+package deleter
+
+// renameExcl renames oldname to newname unless newname exists. Only
+// Linux has an atomic way to refuse an existing newname in reach here, so
+// it is checked for first, which races.
+func renameExcl(oldname, newname string) error {
+	return renameExclRacy(oldname, newname)
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package deleter
+
+import "os"
+
+// isReparsePoint reports whether entry is a symlink; other platforms have
+// no other kind of reparse point.
+func isReparsePoint(entry os.DirEntry) bool {
+	return entry.Type()&os.ModeSymlink != 0
+}
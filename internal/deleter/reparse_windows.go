@@ -0,0 +1,24 @@
+//go:build windows
+
+package deleter
+
+import (
+	"os"
+	"syscall"
+)
+
+// isReparsePoint reports whether entry is a reparse point: a symlink, or
+// a junction or mount point, which Go reports as a plain directory. These
+// are treated like symlinks, so the tree they point into is never
+// descended into.
+func isReparsePoint(entry os.DirEntry) bool {
+	if entry.Type()&os.ModeSymlink != 0 {
+		return true
+	}
+	info, err := entry.Info()
+	if err != nil {
+		return false
+	}
+	attrs, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	return ok && attrs.FileAttributes&syscall.FILE_ATTRIBUTE_REPARSE_POINT != 0
+}
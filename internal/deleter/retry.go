@@ -0,0 +1,138 @@
+package deleter
+
+import (
+	"cmp"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/yourusername/rmrf/internal/reporter"
+)
+
+// retryItem is an entry whose removal failed with a transient error, to be
+// tried again at the end of the run, see WithRetries.
+type retryItem struct {
+	r    *run
+	path string
+	info os.FileInfo
+	sum  string // see erasureHash
+	dir  bool
+	link bool // a symlink or other reparse point
+	err  error
+}
+
+// retryQueue holds the entries of a run awaiting a retry, in the order
+// their removal failed, so a directory always comes after its contents.
+type retryQueue struct {
+	mu    sync.Mutex
+	items []retryItem
+	dirs  map[string]bool // directories holding queued entries
+}
+
+// transient reports whether err is the kind of failure that may go away
+// by itself: a busy file or mount, a running executable, a resource
+// temporarily unavailable, a file locked by another process, or one held
+// open on NFS.
+func transient(err error) bool {
+	return errors.Is(err, syscall.EBUSY) || errors.Is(err, syscall.ETXTBSY) ||
+		errors.Is(err, syscall.EAGAIN) || isLocked(err) || errors.Is(err, ErrNFSHeldOpen)
+}
+
+// retryLater queues item for the retry rounds if its error is transient,
+// or if it is a directory that still holds queued entries and so could
+// not be removed, and reports whether it did. Without retry rounds it
+// never does.
+func (d *Deleter) retryLater(item retryItem) bool {
+	if d.config.RetryRounds <= 0 {
+		return false
+	}
+	q := &d.retries
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !transient(item.err) && !(item.dir && q.dirs[item.path]) {
+		return false
+	}
+	if q.dirs == nil {
+		q.dirs = make(map[string]bool)
+	}
+	q.items = append(q.items, item)
+	q.dirs[filepath.Dir(item.path)] = true
+	return true
+}
+
+// retryTransient tries the queued entries again, in up to RetryRounds
+// rounds, waiting the tuned backoff before the first and twice as long
+// before each next one. Entries still failing after the last round, or
+// failing for another reason, or left when done is closed, are reported as
+// errors.
+func (d *Deleter) retryTransient(done <-chan struct{}) {
+	q := &d.retries
+	items := q.items
+	q.items, q.dirs = nil, nil
+
+	backoff := cmp.Or(d.backoff, localRetryBackoff)
+rounds:
+	for round := 0; round < d.config.RetryRounds && len(items) > 0; round++ {
+		select {
+		case <-done:
+			break rounds
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+
+		var again []retryItem
+		for _, item := range items {
+			err := heldOpen(item.path, item.dir, d.fs.Remove(item.path))
+			switch {
+			case err == nil && item.dir:
+				d.dirRemoved(item.r, item.path, item.info)
+			case err == nil:
+				d.fileRemoved(item.r, item.path, item.info, item.sum, item.link)
+			case transient(err) || item.dir:
+				item.err = err
+				again = append(again, item)
+			default:
+				d.failEntry(item.r, item.path, item.info, err)
+			}
+		}
+		items = again
+	}
+	nfs := false
+	for _, item := range items {
+		d.failEntry(item.r, item.path, item.info, item.err)
+		nfs = nfs || errors.Is(item.err, ErrNFSHeldOpen)
+	}
+	if len(items) > 0 {
+		d.stats.AddWarning("some entries were still busy after the retry rounds")
+	}
+	if nfs {
+		d.stats.AddWarning("files deleted on NFS while still open stay as .nfs files until every process holding them closes them; find those with lsof or fuser")
+	}
+}
+
+// fileRemoved accounts for a file removed from path, which had info; link
+// tells a symlink or other reparse point, whose target stays.
+func (d *Deleter) fileRemoved(r *run, path string, info os.FileInfo, sum string, link bool) {
+	if link {
+		d.stats.AddSymlinkRemoved()
+	} else {
+		d.stats.IncFiles()
+		r.counts.IncFiles()
+		if info != nil {
+			d.addFreed(info)
+		}
+	}
+	d.emit(reporter.EventFileDeleted, path)
+	d.recordDeleted(path, info, sum)
+}
+
+// dirRemoved accounts for a directory removed from path.
+func (d *Deleter) dirRemoved(r *run, path string, info os.FileInfo) {
+	d.stats.IncDirs()
+	r.counts.IncDirs()
+	d.emit(reporter.EventDirDeleted, path)
+	d.recordDeleted(path, info, "")
+}
@@ -0,0 +1,149 @@
+package deleter
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/yourusername/rmrf/internal/trash"
+)
+
+// rootFileSystem confines every call to an os.Root, see WithRoot. Paths,
+// including those of open directories, are translated to be relative to
+// the root, and the root resolves them itself, refusing any symlink or
+// ".." that would lead outside it.
+type rootFileSystem struct {
+	root *os.Root
+}
+
+func (f rootFileSystem) Chmod(name string, mode os.FileMode) error {
+	rel, err := relToRoot(f.root, name)
+	if err != nil {
+		return err
+	}
+	if info, err := f.root.Lstat(rel); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return nil
+	}
+	return fullPathError(f.root.Chmod(rel, mode), name)
+}
+
+func (f rootFileSystem) Remove(name string) error {
+	rel, err := relToRoot(f.root, name)
+	if err != nil {
+		return err
+	}
+	return fullPathError(f.root.Remove(rel), name)
+}
+
+func (f rootFileSystem) Rename(oldname, newname string) error {
+	oldRel, err := relToRoot(f.root, oldname)
+	if err != nil {
+		return err
+	}
+	newRel, err := relToRoot(f.root, newname)
+	if err != nil {
+		return err
+	}
+	if _, err := f.root.Lstat(newRel); !os.IsNotExist(err) {
+		if err == nil {
+			err = fs.ErrExist
+		}
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: err}
+	}
+	err = f.root.Rename(oldRel, newRel)
+	if le, ok := err.(*os.LinkError); ok {
+		le.Old, le.New = oldname, newname
+	}
+	return err
+}
+
+func (f rootFileSystem) ChmodAt(dir *os.File, name string, mode os.FileMode) error {
+	return f.Chmod(filepath.Join(dir.Name(), name), mode)
+}
+
+func (f rootFileSystem) RemoveAt(dir *os.File, name string, isDir bool) error {
+	return f.Remove(filepath.Join(dir.Name(), name))
+}
+
+// RemoveSubvolume reopens dir through the root, so that the subvolume
+// deleted is the one the root resolves.
+func (f rootFileSystem) RemoveSubvolume(dir *os.File, name string) error {
+	rel, err := relToRoot(f.root, dir.Name())
+	if err != nil {
+		return err
+	}
+	parent, err := f.root.Open(rel)
+	if err != nil {
+		return fullPathError(err, dir.Name())
+	}
+	defer parent.Close()
+	return destroySubvolume(parent, name)
+}
+
+func (f rootFileSystem) ClearAttrs(file *os.File) error {
+	return clearAttrs(file)
+}
+
+// DeferRemove is refused: a removal at the next reboot would happen by
+// path, outside the root's control.
+func (f rootFileSystem) DeferRemove(name string) error {
+	return errors.ErrUnsupported
+}
+
+func (f rootFileSystem) Shred(name string, passes int) error {
+	rel, err := relToRoot(f.root, name)
+	if err != nil {
+		return err
+	}
+	open := func() (*os.File, error) {
+		file, err := f.root.OpenFile(rel, os.O_WRONLY, 0)
+		return file, fullPathError(err, name)
+	}
+	lstat := func() (os.FileInfo, error) {
+		info, err := f.root.Lstat(rel)
+		return info, fullPathError(err, name)
+	}
+	return shredPath(name, open, lstat, passes)
+}
+
+func (f rootFileSystem) Trash(name string) (trash.Item, error) {
+	return trash.Item{}, ErrTrashRoot
+}
+
+// relToRoot returns path relative to root, or ErrOutsideRoot if it does
+// not lie within it. Symlinks are left for the root to resolve.
+func relToRoot(root *os.Root, path string) (string, error) {
+	base, err := filepath.Abs(root.Name())
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if !isWithin(base, abs) {
+		return "", fmt.Errorf("%w: %s is not within %s", ErrOutsideRoot, path, base)
+	}
+	return filepath.Rel(base, abs)
+}
+
+// openInRoot opens the directory at path through root.
+func openInRoot(root *os.Root, path string) (*os.File, error) {
+	rel, err := relToRoot(root, path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := root.Open(rel)
+	return f, fullPathError(err, path)
+}
+
+// fullPathError puts path back into err in place of the root-relative
+// path os.Root reports, so errors read as they would without a root.
+func fullPathError(err error, path string) error {
+	if pe, ok := err.(*os.PathError); ok {
+		pe.Path = path
+	}
+	return err
+}
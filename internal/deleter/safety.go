@@ -2,31 +2,195 @@ package deleter
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 )
 
 var (
-	ErrDangerousPath = errors.New("dangerous path specified")
-	ErrNotExist      = errors.New("path does not exist")
+	ErrDangerousPath   = errors.New("dangerous path specified")
+	ErrNotExist        = errors.New("path does not exist")
+	ErrProtectedPath   = errors.New("protected path specified")
+	ErrPreflightDenied = errors.New("preflight permission check failed")
+	ErrCancelled       = errors.New("deletion cancelled")
+	ErrTrashFilters    = errors.New("trash mode moves whole targets and cannot be combined with filters or interactive mode")
+	ErrMountPoint      = errors.New("target is a mount point")
+	ErrNotConfirmed    = errors.New("deletion not confirmed")
+	ErrPlanDrift       = errors.New("changed since the plan was made")
+	ErrDirReplaced     = errors.New("directory replaced during deletion")
+	ErrOutsideRoot     = errors.New("path outside the confining root")
+	ErrAttrProtected   = errors.New("protected by an immutable or append-only attribute (use --clear-attrs)")
+	ErrTrashRoot       = errors.New("trash mode moves targets out of the confining root and cannot be combined with it")
+	ErrSpecialFile     = errors.New("special file left in place")
+	ErrTrashIgnore     = errors.New("trash mode moves whole targets and cannot honor their .rmrfignore")
+	ErrInUse           = errors.New("target contains the working directory or the running executable")
+	ErrTrashShred      = errors.New("trash mode keeps files restorable and cannot be combined with shredding")
+	ErrTrashArchive    = errors.New("trash mode moves whole targets and cannot be combined with archiving")
+	ErrArchiveFormat   = errors.New("unsupported archive format (want .tar, .tar.gz, .tgz, .tar.zst or .tzst)")
+	ErrArchiveInside   = errors.New("archive inside a target")
+	ErrSnapshot        = errors.New("cannot snapshot before deleting")
+	ErrHomeDir         = errors.New("target is the home directory, directly in it or above it")
+	ErrDetachFilters   = errors.New("detach mode moves whole targets aside and cannot be combined with filters, interactive mode, archiving or trash mode")
+	ErrDetachIgnore    = errors.New("detach mode moves whole targets aside and cannot honor their .rmrfignore")
+	ErrActiveWriter    = errors.New("another process is creating entries in the targets")
+	ErrNFSHeldOpen     = errors.New("still open in some process, kept by NFS as a .nfs file until closed")
 )
 
+// validatePath refuses targets that do not exist, and targets that, once
+// cleaned, made absolute and stripped of symlinks, are a dangerous path or
+// contain one. A dangerous path is checked both as written and resolved,
+// so "/bin" is still caught on systems where it links to "/usr/bin".
+// Protected path patterns, those of config.SystemProtectedFile included
+// whatever the options, are matched against both forms as well. Mount
+// points are refused too unless WithAllowMountpoint is set, the home
+// directory, its entries and its ancestors unless WithAllowHome is,
+// targets containing the working directory or the executable unless
+// WithForce is, and with WithRoot, anything outside the root.
 func (d *Deleter) validatePath(path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return ErrNotExist
+	}
+	if d.config.Root != nil {
+		if _, err := relToRoot(d.config.Root, path); err != nil {
+			return err
+		}
+	}
+
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return err
+	}
+	if err := d.checkGuarded(path); err != nil {
+		return err
+	}
+
+	if !d.config.AllowHome {
+		if home, ok := homeDir(); ok && (isWithin(resolved, home) || filepath.Dir(resolved) == home) {
+			return fmt.Errorf("%w: %s (use --allow-home to delete it anyway)", ErrHomeDir, resolved)
+		}
+	}
+
+	if !d.config.Force {
+		if err := inUse(resolved); err != nil {
+			return err
+		}
+	}
+
+	if !d.config.AllowMountpoint && isMountPoint(resolved) {
+		return fmt.Errorf("%w: %s (use --allow-mountpoint to delete its contents anyway)", ErrMountPoint, resolved)
+	}
+
+	return nil
+}
+
+// checkGuarded returns ErrDangerousPath if path is a dangerous path or
+// contains one, and ErrProtectedPath if a protected path pattern forbids
+// it. Both its resolved and its canonical form are checked, the former
+// only if path resolves: a dangling symlink is judged by itself.
+func (d *Deleter) checkGuarded(path string) error {
+	var candidates []string
+	if resolved, err := resolvePath(path); err == nil {
+		candidates = append(candidates, resolved)
+	}
+	canonical, err := canonicalPath(path)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(candidates, canonical) {
+		candidates = append(candidates, canonical)
+	}
+
 	for _, dangerous := range d.config.DangerousPaths {
-		if path == dangerous {
-			return ErrDangerousPath
+		dangers := []string{filepath.Clean(dangerous)}
+		if r, err := resolvePath(dangerous); err == nil {
+			dangers = append(dangers, r)
+		}
+		for _, c := range candidates {
+			for _, danger := range dangers {
+				if isWithin(c, danger) {
+					return fmt.Errorf("%w: %s", ErrDangerousPath, c)
+				}
+			}
 		}
 	}
 
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return ErrNotExist
+	if d.protectedErr != nil {
+		return fmt.Errorf("%w: %w", ErrProtectedPath, d.protectedErr)
+	}
+	for _, c := range candidates {
+		if pattern, ok := protectedBy(d.protected, c); ok {
+			return fmt.Errorf("%w: %s (protected by %q)", ErrProtectedPath, c, pattern)
+		}
+	}
+	return nil
+}
+
+// homeDir returns the user's home directory, resolved.
+func homeDir() (string, bool) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", false
 	}
+	home, err = resolvePath(home)
+	return home, err == nil
+}
 
+// inUse returns ErrInUse if resolved, a resolved target, is or contains
+// the working directory or the running executable.
+func inUse(resolved string) error {
+	var inside []string
+	if wd, err := os.Getwd(); err == nil {
+		inside = append(inside, wd)
+	}
+	if exe, err := os.Executable(); err == nil {
+		inside = append(inside, exe)
+	}
+	for _, path := range inside {
+		if path, err := filepath.EvalSymlinks(path); err == nil && isWithin(resolved, path) {
+			return fmt.Errorf("%w: %s (use --force to delete it anyway)", ErrInUse, resolved)
+		}
+	}
 	return nil
 }
 
-func (d *Deleter) makeDeletable(path string) error {
-	if d.config.DryRun {
-		return nil
+// canonicalPath returns path made absolute and clean, with symlinks
+// resolved in all but its last component: the target that deleting path
+// removes, a symlink itself rather than what it points to. Targets are
+// deleted by this form, so "a/../b" or a path through a symlinked
+// directory can't come to mean something other than what was validated.
+func canonicalPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Dir(abs))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filepath.Base(abs)), nil
+}
+
+// resolvePath returns the absolute, symlink-free form of path.
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
 	}
-	return os.Chmod(path, 0700)
+	return filepath.EvalSymlinks(abs)
+}
+
+// isWithin reports whether path equals dir or lies below it. Both must be
+// clean absolute paths.
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func (d *Deleter) makeDeletable(path string) error {
+	return d.fs.Chmod(path, 0700)
 }
@@ -0,0 +1,204 @@
+package deleter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/rmrf/internal/config"
+	"github.com/yourusername/rmrf/internal/reporter"
+)
+
+// safetyTree creates sub, sub/file, a symlink usr to /usr and a symlink
+// lnk to sub in a temp directory, and returns the directory, resolved.
+func safetyTree(t *testing.T) string {
+	t.Helper()
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sub", "file"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	for name, target := range map[string]string{"usr": "/usr", "lnk": "sub"} {
+		if err := os.Symlink(target, filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestValidatePath(t *testing.T) {
+	if _, err := os.Stat("/usr/share"); err != nil {
+		t.Skip("no /usr/share")
+	}
+	dir := safetyTree(t)
+	d := New(config.WithReporter(reporter.NoopReporter{}))
+
+	tests := []struct {
+		name string
+		path string
+		want error
+	}{
+		{"plain", dir + "/sub", nil},
+		{"trailing slash", dir + "/sub/", nil},
+		{"dot dot", dir + "/sub/../sub/file", nil},
+		{"dot dot to root", dir + strings.Repeat("/..", 32), ErrDangerousPath},
+		{"missing", dir + "/missing", ErrNotExist},
+		{"dot dot to missing", dir + "/sub/../missing", ErrNotExist},
+		{"dangerous with trailing slash", "/usr/", ErrDangerousPath},
+		{"dangerous through dot dot", "/usr/share/../../etc", ErrDangerousPath},
+		{"symlink to /usr", dir + "/usr", ErrDangerousPath},
+		{"symlink to /usr with trailing slash", dir + "/usr/", ErrDangerousPath},
+		{"through symlink into /usr", dir + "/usr/share/..", ErrDangerousPath},
+		{"symlink to a directory", dir + "/lnk", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := d.validatePath(tt.path)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("validatePath(%q) = %v, want %v", tt.path, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalPath(t *testing.T) {
+	dir := safetyTree(t)
+	usr, err := filepath.EvalSymlinks("/usr")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"plain", dir + "/sub", dir + "/sub"},
+		{"trailing slash", dir + "/sub/", dir + "/sub"},
+		{"dot dot", dir + "/sub/../sub/file", dir + "/sub/file"},
+		{"symlink kept", dir + "/lnk", dir + "/lnk"},
+		{"symlink to /usr kept", dir + "/usr", dir + "/usr"},
+		{"through symlinked directory", dir + "/lnk/file", dir + "/sub/file"},
+		{"through symlink into /usr", dir + "/usr/share", filepath.Join(usr, "share")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := canonicalPath(tt.path)
+			if err != nil {
+				t.Fatalf("canonicalPath(%q): %v", tt.path, err)
+			}
+			if got != tt.want {
+				t.Errorf("canonicalPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDedupeSymlinkTarget checks that a symlink to a directory X does not
+// cover X: given both, both are kept as targets and both are deleted.
+func TestDedupeSymlinkTarget(t *testing.T) {
+	dir := safetyTree(t)
+	link, target := filepath.Join(dir, "lnk"), filepath.Join(dir, "sub")
+
+	// Following the link deletes what it points to, which X covers.
+	for _, tt := range []struct {
+		name   string
+		policy config.SymlinkPolicy
+		want   int
+	}{
+		{"unlink", config.SymlinkUnlink, 2},
+		{"skip", config.SymlinkSkip, 2},
+		{"follow", config.SymlinkFollow, 1},
+	} {
+		d := New(config.WithReporter(reporter.NoopReporter{}), config.WithSymlinkPolicy(tt.policy))
+		roots, err := d.dedupeTargets([]string{link, target})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(roots) != tt.want {
+			t.Errorf("%s: dedupeTargets kept %q, want %d targets", tt.name, roots, tt.want)
+		}
+	}
+
+	d := New(config.WithReporter(reporter.NoopReporter{}))
+	if _, err := d.DeleteMany(link, target); err != nil {
+		t.Fatalf("DeleteMany: %v", err)
+	}
+	for _, path := range []string{link, target} {
+		if _, err := os.Lstat(path); !os.IsNotExist(err) {
+			t.Errorf("%s still exists: %v", path, err)
+		}
+	}
+}
+
+// TestValidatePathInUse checks the refusals of the home directory, the
+// working directory and the directory of the running executable, their
+// parents and symlinks to any of them, and the flags that lift them:
+// --force all but the home directory one, --allow-home only that one.
+func TestValidatePathInUse(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skip("no executable path")
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		t.Fatal(err)
+	}
+	dir := safetyTree(t)
+	home, wd := filepath.Join(dir, "home", "user"), filepath.Join(dir, "work", "wd")
+	for _, path := range []string{filepath.Join(home, "Documents"), wd} {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("HOME", home)
+	t.Chdir(wd)
+
+	type target struct {
+		name                   string
+		path                   string
+		want, force, allowHome error
+	}
+	targets := []target{
+		{"home", home, ErrHomeDir, ErrHomeDir, nil},
+		{"parent of home", filepath.Dir(home), ErrHomeDir, ErrHomeDir, nil},
+		{"entry of home", filepath.Join(home, "Documents"), ErrHomeDir, ErrHomeDir, nil},
+		{"working directory", wd, ErrInUse, nil, ErrInUse},
+		{"parent of the working directory", filepath.Dir(wd), ErrInUse, nil, ErrInUse},
+		{"executable directory", filepath.Dir(exe), ErrInUse, nil, ErrInUse},
+	}
+	links := t.TempDir()
+	for _, tt := range targets {
+		link := filepath.Join(links, strings.ReplaceAll(tt.name, " ", "-"))
+		if err := os.Symlink(tt.path, link); err != nil {
+			t.Fatal(err)
+		}
+		tt.name, tt.path = "symlink to "+tt.name, link
+		targets = append(targets, tt)
+	}
+
+	quiet := config.WithReporter(reporter.NoopReporter{})
+	for _, tt := range targets {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, c := range []struct {
+				flag string
+				d    *Deleter
+				want error
+			}{
+				{"none", New(quiet), tt.want},
+				{"--force", New(quiet, config.WithForce(true)), tt.force},
+				{"--allow-home", New(quiet, config.WithAllowHome(true)), tt.allowHome},
+			} {
+				if err := c.d.validatePath(tt.path); !errors.Is(err, c.want) {
+					t.Errorf("with %s: validatePath(%q) = %v, want %v", c.flag, tt.path, err, c.want)
+				}
+			}
+		})
+	}
+}
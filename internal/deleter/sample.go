@@ -0,0 +1,103 @@
+package deleter
+
+import (
+	"context"
+	"io/fs"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+)
+
+// sampledDir is what one ReadDir tells us about a directory.
+type sampledDir struct {
+	entries int
+	bytes   int64
+	subdirs []string
+}
+
+// sampleTree estimates the size of the tree at path with Knuth's random
+// probe method: each probe walks from path down a uniformly random
+// subdirectory at every level, and weights what it sees at each level by
+// the product of the branching factors above it. Every probe is an
+// unbiased estimate of the whole tree; their spread gives the confidence
+// interval. Directories are read at most once however many probes pass
+// through them. A path that is not a directory is measured exactly.
+func sampleTree(ctx context.Context, path string, probes int, visit func(string, fs.DirEntry)) treeSize {
+	info, err := os.Lstat(path)
+	if err != nil || !info.IsDir() {
+		return measureTree(ctx, path, visit)
+	}
+	if visit != nil {
+		visit(path, fs.FileInfoToDirEntry(info))
+	}
+
+	cache := make(map[string]*sampledDir)
+	read := func(dir string) *sampledDir {
+		if s, ok := cache[dir]; ok {
+			return s
+		}
+		s := &sampledDir{}
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			if visit != nil {
+				visit(filepath.Join(dir, entry.Name()), entry)
+			}
+			s.entries++
+			switch {
+			case entry.IsDir():
+				s.subdirs = append(s.subdirs, filepath.Join(dir, entry.Name()))
+			case entry.Type().IsRegular():
+				if info, err := entry.Info(); err == nil {
+					s.bytes += info.Size()
+				}
+			}
+		}
+		cache[dir] = s
+		return s
+	}
+
+	entries := make([]float64, 0, probes)
+	bytes := make([]float64, 0, probes)
+	for i := 0; i < probes && ctx.Err() == nil; i++ {
+		weight, n, b := 1.0, 1.0, 0.0 // n counts path itself
+		for dir := path; ; {
+			s := read(dir)
+			n += weight * float64(s.entries)
+			b += weight * float64(s.bytes)
+			if len(s.subdirs) == 0 {
+				break
+			}
+			weight *= float64(len(s.subdirs))
+			dir = s.subdirs[rand.Intn(len(s.subdirs))]
+		}
+		entries = append(entries, n)
+		bytes = append(bytes, b)
+	}
+
+	n, nMargin := meanAndMargin(entries)
+	b, bMargin := meanAndMargin(bytes)
+	return treeSize{Entries: int(math.Round(n)), Bytes: int64(b), EntriesMargin: nMargin, BytesMargin: bMargin}
+}
+
+// meanAndMargin returns the mean of xs and the half-width of its 95%
+// confidence interval.
+func meanAndMargin(xs []float64) (mean, margin float64) {
+	if len(xs) == 0 {
+		return 0, 0
+	}
+	for _, x := range xs {
+		mean += x
+	}
+	mean /= float64(len(xs))
+	if len(xs) < 2 {
+		return mean, 0
+	}
+
+	var ss float64
+	for _, x := range xs {
+		ss += (x - mean) * (x - mean)
+	}
+	stderr := math.Sqrt(ss/float64(len(xs)-1)) / math.Sqrt(float64(len(xs)))
+	return mean, 1.96 * stderr
+}
@@ -0,0 +1,173 @@
+package deleter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/rmrf/internal/reporter"
+)
+
+// scanListChildren bounds how many top-level children of each target the
+// confirmation summary lists, largest first.
+const scanListChildren = 10
+
+// treeSize counts what a tree would free. Sampled counts carry the
+// half-width of their 95% confidence interval; exact ones have none.
+type treeSize struct {
+	Entries       int
+	Bytes         int64
+	EntriesMargin float64
+	BytesMargin   float64
+}
+
+// add sums two sizes. The estimates are independent, so their variances,
+// not their margins, add up.
+func (t *treeSize) add(o treeSize) {
+	t.Entries += o.Entries
+	t.Bytes += o.Bytes
+	t.EntriesMargin = math.Hypot(t.EntriesMargin, o.EntriesMargin)
+	t.BytesMargin = math.Hypot(t.BytesMargin, o.BytesMargin)
+}
+
+func (t treeSize) String() string {
+	if t.EntriesMargin == 0 && t.BytesMargin == 0 {
+		return fmt.Sprintf("%d entries, %s", t.Entries, reporter.FormatBytes(t.Bytes))
+	}
+	return fmt.Sprintf("~%d ± %.0f entries, ~%s ± %s", t.Entries, t.EntriesMargin,
+		reporter.FormatBytes(t.Bytes), reporter.FormatBytes(int64(t.BytesMargin)))
+}
+
+// measure sizes up path, by sampling if WithSampledEstimate is set and by
+// a full walk otherwise. Every entry it comes across is checked for the
+// sensitive scan, if one is on, see confirmSensitive.
+func (d *Deleter) measure(ctx context.Context, path string) treeSize {
+	var visit func(string, fs.DirEntry)
+	if d.sensitive != nil {
+		visit = d.sensitive.visit
+	}
+	if d.config.SampleProbes > 0 {
+		return sampleTree(ctx, path, d.config.SampleProbes, visit)
+	}
+	return measureTree(ctx, path, visit)
+}
+
+// measureTree walks path without following symlinks, counting path itself
+// and everything below it, and passing each entry to visit unless it is
+// nil. Unreadable parts are skipped; the figure is a pre-scan estimate,
+// not a promise.
+func measureTree(ctx context.Context, path string, visit func(string, fs.DirEntry)) treeSize {
+	var size treeSize
+	filepath.WalkDir(path, func(p string, entry fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return nil
+		}
+		if visit != nil {
+			visit(p, entry)
+		}
+		size.Entries++
+		if entry.Type().IsRegular() {
+			if info, err := entry.Info(); err == nil {
+				size.Bytes += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+// estimateEntries walks roots to count the entries a run will process,
+// as progress counts them: everything below a directory target, or a
+// whole other target as one entry.
+func (d *Deleter) estimateEntries(ctx context.Context, roots []string) int {
+	n := 0
+	for _, root := range roots {
+		info, err := os.Stat(root)
+		if err != nil || !info.IsDir() || d.config.Trash {
+			n++
+			continue
+		}
+		n += d.measure(ctx, root).Entries - 1
+	}
+	return n
+}
+
+// confirmLarge pre-scans roots and, if they hold more entries or bytes
+// than the configured thresholds, describes them and asks once whether to
+// go ahead. Dry runs never prompt.
+func (d *Deleter) confirmLarge(ctx context.Context, roots []string) error {
+	if (d.config.ConfirmEntries <= 0 && d.config.ConfirmBytes <= 0) || d.config.DryRun {
+		return nil
+	}
+
+	var total treeSize
+	var summary strings.Builder
+	for _, root := range roots {
+		fmt.Fprintf(&summary, "  %s\n", root)
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			total.add(d.measure(ctx, root))
+			continue
+		}
+
+		type child struct {
+			name string
+			size treeSize
+		}
+		children := make([]child, len(entries))
+		total.Entries++ // the root itself
+		if d.sensitive != nil {
+			if info, err := os.Lstat(root); err == nil {
+				d.sensitive.visit(root, fs.FileInfoToDirEntry(info))
+			}
+		}
+		for i, entry := range entries {
+			children[i] = child{entry.Name(), d.measure(ctx, filepath.Join(root, entry.Name()))}
+			total.add(children[i].size)
+		}
+		sort.Slice(children, func(i, j int) bool { return children[i].size.Bytes > children[j].size.Bytes })
+		for i, c := range children {
+			if i == scanListChildren {
+				fmt.Fprintf(&summary, "    ... and %d more\n", len(children)-i)
+				break
+			}
+			fmt.Fprintf(&summary, "    %-30s %s\n", c.name, c.size)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrCancelled, err)
+	}
+
+	overEntries := d.config.ConfirmEntries > 0 && total.Entries > d.config.ConfirmEntries
+	overBytes := d.config.ConfirmBytes > 0 && total.Bytes > d.config.ConfirmBytes
+	if !overEntries && !overBytes {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	fmt.Fprintf(d.promptOut, "about to delete %s in %d target(s):\n%s", total, len(roots), summary.String())
+	fmt.Fprint(d.promptOut, "proceed? [y/N] ")
+	reply, err := d.promptIn.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	switch strings.ToLower(strings.TrimSpace(reply)) {
+	case "y", "yes":
+		return nil
+	}
+	if reply == "" {
+		fmt.Fprintln(d.promptOut)
+	}
+	return ErrNotConfirmed
+}
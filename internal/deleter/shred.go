@@ -0,0 +1,102 @@
+package deleter
+
+import (
+	crand "crypto/rand"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+)
+
+// shredBuffer is how much is written at a time when shredding.
+const shredBuffer = 1 << 20
+
+// shredPath overwrites the regular file at name in place, see WithShred.
+// It refuses anything that is not a regular file, or is swapped for
+// another file between being checked and opened.
+func shredPath(name string, open func() (*os.File, error), lstat func() (os.FileInfo, error), passes int) error {
+	before, err := lstat()
+	if err != nil {
+		return err
+	}
+	if !before.Mode().IsRegular() {
+		return fmt.Errorf("shredding %s: not a regular file", name)
+	}
+	f, err := open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	after, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if !os.SameFile(before, after) {
+		return fmt.Errorf("shredding %s: %w", name, ErrDirReplaced)
+	}
+	if err := shredFile(f, after.Size(), passes); err != nil {
+		return fmt.Errorf("shredding %s: %w", name, err)
+	}
+	return nil
+}
+
+// shredFile overwrites the first size bytes of f passes times, syncing
+// after each pass: with random data, except for a final pass of zeros
+// when there is more than one.
+func shredFile(f *os.File, size int64, passes int) error {
+	var seed [32]byte
+	if _, err := crand.Read(seed[:]); err != nil {
+		return err
+	}
+	random := rand.NewChaCha8(seed)
+	buf := make([]byte, min(size, shredBuffer))
+
+	for pass := range passes {
+		zero := passes > 1 && pass == passes-1
+		if zero {
+			clear(buf)
+		}
+		for off := int64(0); off < size; off += int64(len(buf)) {
+			chunk := buf[:min(int64(len(buf)), size-off)]
+			if !zero {
+				if _, err := io.ReadFull(random, chunk); err != nil {
+					return err
+				}
+			}
+			if _, err := f.WriteAt(chunk, off); err != nil {
+				return err
+			}
+		}
+		if err := f.Sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shredTarget decides whether files under r's target are shredded, and
+// warns if WithShred asked for it where overwriting in place is no
+// guarantee the old contents are gone.
+func (d *Deleter) shredTarget(r *run) {
+	if d.config.ShredPasses <= 0 || d.config.DryRun {
+		return
+	}
+	if reason := shredCaveat(r.root); reason != "" {
+		d.stats.AddWarning(fmt.Sprintf("%s: not shredding, %s, where overwriting a file need not reach its old contents", r.root, reason))
+		return
+	}
+	r.shred = true
+}
+
+// shred overwrites the file at path before it is removed, unless it has
+// other hard links whose contents that would destroy too.
+func (d *Deleter) shred(r *run, path string, entry os.DirEntry, info os.FileInfo) error {
+	if !r.shred || !entry.Type().IsRegular() || info == nil {
+		return nil
+	}
+	if linkCount(info) > 1 {
+		d.stats.AddWarning(fmt.Sprintf("%s: not shredding, it has other hard links", path))
+		return nil
+	}
+	return d.fs.Shred(path, d.config.ShredPasses)
+}
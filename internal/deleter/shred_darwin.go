@@ -0,0 +1,24 @@
+package deleter
+
+import "syscall"
+
+// shredCaveat returns why overwriting files under path in place may
+// leave their old contents on disk: APFS is copy-on-write. It returns ""
+// for other filesystems.
+func shredCaveat(path string) string {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return ""
+	}
+	name := make([]byte, 0, len(st.Fstypename))
+	for _, c := range st.Fstypename {
+		if c == 0 {
+			break
+		}
+		name = append(name, byte(c))
+	}
+	if string(name) == "apfs" {
+		return "APFS is copy-on-write"
+	}
+	return ""
+}
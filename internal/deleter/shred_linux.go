@@ -0,0 +1,41 @@
+package deleter
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// shredCaveat returns why overwriting files under path in place may
+// leave their old contents on disk: a copy-on-write or log-structured
+// filesystem writes the new data elsewhere, and a solid-state drive
+// remaps the blocks it is given. It returns "" if neither applies, as
+// far as can be told.
+func shredCaveat(path string) string {
+	switch name, _ := fsType(path); name {
+	case "btrfs", "zfs", "bcachefs":
+		return name + " is copy-on-write"
+	case "f2fs":
+		return "f2fs is log-structured"
+	}
+
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return ""
+	}
+	dev := uint64(st.Dev)
+	major := (dev>>8)&0xfff | (dev>>32)&^0xfff
+	minor := dev&0xff | (dev>>12)&^0xff
+	// A partition has no queue of its own; its disk, one level up, does.
+	block := fmt.Sprintf("/sys/dev/block/%d:%d", major, minor)
+	for _, file := range []string{block + "/queue/rotational", block + "/../queue/rotational"} {
+		if b, err := os.ReadFile(file); err == nil {
+			if strings.TrimSpace(string(b)) == "0" {
+				return "it is on a solid-state drive"
+			}
+			return ""
+		}
+	}
+	return ""
+}
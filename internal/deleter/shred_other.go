@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package deleter
+
+// shredCaveat can't tell what backs path here, so it never objects.
+func shredCaveat(path string) string {
+	return ""
+}
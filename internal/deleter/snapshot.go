@@ -0,0 +1,131 @@
+package deleter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// btrfsSubvolInode is the inode number of every btrfs subvolume's root
+// directory.
+const btrfsSubvolInode = 256
+
+// snapshotTargets takes a read-only snapshot of each btrfs subvolume or
+// ZFS dataset holding a target, once per subvolume or dataset, and
+// records its name in Stats.Snapshots. Any target elsewhere, or a
+// snapshot that fails, stops the run before anything is deleted. Dry runs
+// take none.
+func (d *Deleter) snapshotTargets(ctx context.Context, roots []string) error {
+	if !d.config.Snapshot || d.config.DryRun {
+		return nil
+	}
+	stamp := "rmrf-" + time.Now().Format("20060102-150405")
+	taken := map[string]bool{}
+	for _, root := range roots {
+		var (
+			source string
+			take   func() (string, error)
+		)
+		switch fs, _ := fsType(root); fs {
+		case "btrfs":
+			subvol, err := btrfsSubvolume(root)
+			if err != nil {
+				return fmt.Errorf("%w: %s: %w", ErrSnapshot, root, err)
+			}
+			source = subvol
+			take = func() (string, error) { return snapshotBtrfs(ctx, subvol, stamp, roots) }
+		case "zfs":
+			dataset, err := zfsDataset(ctx, root)
+			if err != nil {
+				return fmt.Errorf("%w: %s: %w", ErrSnapshot, root, err)
+			}
+			source = dataset
+			take = func() (string, error) { return snapshotZFS(ctx, dataset, stamp) }
+		default:
+			return fmt.Errorf("%w: %s is not on btrfs or ZFS", ErrSnapshot, root)
+		}
+		if taken[source] {
+			continue
+		}
+		name, err := take()
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrSnapshot, source, err)
+		}
+		taken[source] = true
+		d.stats.AddSnapshot(name)
+	}
+	return nil
+}
+
+// btrfsSubvolume returns the root of the btrfs subvolume holding path.
+func btrfsSubvolume(path string) (string, error) {
+	for dir := path; ; dir = filepath.Dir(dir) {
+		info, err := os.Lstat(dir)
+		if err != nil {
+			return "", err
+		}
+		if _, ino, ok := fileID(info); ok && ino == btrfsSubvolInode && info.IsDir() {
+			return dir, nil
+		}
+		if filepath.Dir(dir) == dir {
+			return "", fmt.Errorf("no subvolume root above %s", path)
+		}
+	}
+}
+
+// snapshotBtrfs snapshots subvol read-only as name, inside it, or next
+// to it if a target would take a snapshot inside it along.
+func snapshotBtrfs(ctx context.Context, subvol, name string, roots []string) (string, error) {
+	dest := filepath.Join(subvol, "."+name)
+	for _, root := range roots {
+		if isWithin(root, dest) {
+			dest = filepath.Join(filepath.Dir(subvol), "."+filepath.Base(subvol)+"-"+name)
+			break
+		}
+	}
+	if err := runCommand(ctx, "btrfs", "subvolume", "snapshot", "-r", subvol, dest); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// zfsDataset returns the ZFS dataset holding path.
+func zfsDataset(ctx context.Context, path string) (string, error) {
+	out, err := exec.CommandContext(ctx, "zfs", "list", "-H", "-o", "name", path).Output()
+	if err != nil {
+		return "", commandError("zfs", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// snapshotZFS snapshots dataset as dataset@name.
+func snapshotZFS(ctx context.Context, dataset, name string) (string, error) {
+	snap := dataset + "@" + name
+	if err := runCommand(ctx, "zfs", "snapshot", snap); err != nil {
+		return "", err
+	}
+	return snap, nil
+}
+
+// runCommand runs a command, failing with its error output if it fails.
+func runCommand(ctx context.Context, name string, args ...string) error {
+	_, err := exec.CommandContext(ctx, name, args...).Output()
+	return commandError(name, err)
+}
+
+// commandError describes a failed command by its error output, if any.
+func commandError(name string, err error) error {
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && len(exit.Stderr) > 0 {
+		return fmt.Errorf("%s: %s", name, strings.TrimSpace(string(exit.Stderr)))
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
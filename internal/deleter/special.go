@@ -0,0 +1,28 @@
+package deleter
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/yourusername/rmrf/internal/config"
+)
+
+// isSpecial reports whether entry is a FIFO, a socket or a device node.
+func isSpecial(entry os.DirEntry) bool {
+	return entry.Type()&(os.ModeNamedPipe|os.ModeSocket|os.ModeDevice) != 0
+}
+
+// leaveSpecial reports whether entry, at path, is a special file that the
+// SpecialPolicy keeps, after recording it as kept or as an error.
+func (d *Deleter) leaveSpecial(r *run, path string, entry os.DirEntry) bool {
+	if d.config.SpecialPolicy == config.SpecialDelete || !isSpecial(entry) {
+		return false
+	}
+	if d.config.SpecialPolicy == config.SpecialError {
+		d.fail(r, fmt.Errorf("%w: %s", ErrSpecialFile, path))
+	} else {
+		d.stats.AddKept()
+	}
+	d.emitSkipped(path, "special file")
+	return true
+}
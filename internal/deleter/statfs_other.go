@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package deleter
+
+func usedInodes(path string) (int, bool) {
+	return 0, false
+}
+
+func freeBytes(path string) (int64, bool) {
+	return 0, false
+}
@@ -0,0 +1,28 @@
+//go:build linux || darwin
+
+package deleter
+
+import "syscall"
+
+// usedInodes reports how many inodes are in use on the filesystem holding
+// path. It is a rough upper bound for the number of entries under path.
+func usedInodes(path string) (int, bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, false
+	}
+	if st.Files < st.Ffree {
+		return 0, false
+	}
+	return int(st.Files - st.Ffree), true
+}
+
+// freeBytes reports the space available to unprivileged users on the
+// filesystem holding path.
+func freeBytes(path string) (int64, bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, false
+	}
+	return int64(st.Bavail) * int64(st.Bsize), true
+}
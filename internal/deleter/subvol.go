@@ -0,0 +1,49 @@
+package deleter
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// isSubvolume reports whether the directory at path, with inode number
+// ino, is the root of a btrfs subvolume.
+func isSubvolume(path string, ino uint64) bool {
+	if ino != btrfsSubvolInode {
+		return false
+	}
+	fs, _ := fsType(path)
+	return fs == "btrfs"
+}
+
+// leaveSubvolume reports whether the directory at path inside a target,
+// with device and inode numbers dev and ino, is a nested btrfs subvolume
+// that is to be kept, and if so counts it: rmdir can't remove one, so
+// without WithDeleteSubvolumes they are left in place.
+func (d *Deleter) leaveSubvolume(t *dirTask, path string, dev, ino uint64) (subvol, keep bool) {
+	if dev == t.dev || !isSubvolume(path, ino) {
+		return false, false
+	}
+	if d.config.DeleteSubvolumes {
+		return true, false
+	}
+	d.stats.AddSubvolumeSkipped()
+	d.emitSkipped(path, "btrfs subvolume")
+	return true, true
+}
+
+// removeSubvolume deletes the emptied btrfs subvolume at path, as
+// removeDir does a directory. If parent, the open directory containing
+// it, is nil, it is opened here.
+func (d *Deleter) removeSubvolume(r *run, parent *os.File, path string) {
+	if parent == nil {
+		dir, err := os.Open(filepath.Dir(path))
+		if err != nil {
+			d.fail(r, err)
+			return
+		}
+		defer dir.Close()
+		parent = dir
+	}
+	remove := func() error { return d.fs.RemoveSubvolume(parent, filepath.Base(path)) }
+	d.removeEmptyDir(r, parent, path, remove)
+}
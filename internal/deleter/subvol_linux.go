@@ -0,0 +1,34 @@
+package deleter
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+// btrfsIocSnapDestroy is BTRFS_IOC_SNAP_DESTROY, _IOW(0x94, 15, struct
+// btrfs_ioctl_vol_args).
+const btrfsIocSnapDestroy = 0x5000940F
+
+// btrfsVolArgs is struct btrfs_ioctl_vol_args.
+type btrfsVolArgs struct {
+	fd   int64
+	name [4088]byte
+}
+
+// destroySubvolume deletes the btrfs subvolume name in dir, contents and
+// all, as "btrfs subvolume delete" does. It takes root, or a filesystem
+// mounted with user_subvol_rm_allowed.
+func destroySubvolume(dir *os.File, name string) error {
+	var args btrfsVolArgs
+	if len(name) >= len(args.name) {
+		return &os.PathError{Op: "delete subvolume", Path: filepath.Join(dir.Name(), name), Err: syscall.ENAMETOOLONG}
+	}
+	copy(args.name[:], name)
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dir.Fd(), btrfsIocSnapDestroy, uintptr(unsafe.Pointer(&args)))
+	if errno != 0 {
+		return &os.PathError{Op: "delete subvolume", Path: filepath.Join(dir.Name(), name), Err: errno}
+	}
+	return nil
+}
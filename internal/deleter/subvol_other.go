@@ -0,0 +1,13 @@
+//go:build !linux
+
+package deleter
+
+import (
+	"errors"
+	"os"
+)
+
+// destroySubvolume is only possible on Linux, where btrfs lives.
+func destroySubvolume(dir *os.File, name string) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,85 @@
+package deleter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yourusername/rmrf/internal/config"
+)
+
+// symlinkFarmMinEntries keeps tiny trees, where a couple of links can make
+// up most of the entries, from tripping the symlink farm warning.
+const symlinkFarmMinEntries = 100
+
+// checkSymlinkFarm adds a warning to the stats when an unusually large
+// share of the entries seen were symlinks, which is typical of
+// pathological or malicious archives.
+func (d *Deleter) checkSymlinkFarm() {
+	ratio := d.config.SymlinkFarmRatio
+	seen, links := d.stats.EntriesSeen, d.stats.SymlinksSkipped+d.stats.SymlinksRemoved
+	if ratio <= 0 || seen < symlinkFarmMinEntries {
+		return
+	}
+
+	if share := float64(links) / float64(seen); share > ratio {
+		d.stats.AddWarning(fmt.Sprintf("%.0f%% of entries were symlinks, tree may be a symlink farm", share*100))
+	}
+}
+
+// fileKey identifies a directory by device and inode.
+type fileKey struct{ dev, ino uint64 }
+
+// enter records that t's directory is being cleared, with SymlinkFollow.
+// It reports false if it already was, reached another way through a
+// symlink, in which case it is left to that.
+func (d *Deleter) enter(r *run, t *dirTask) bool {
+	if d.config.SymlinkPolicy != config.SymlinkFollow || t.ino == 0 {
+		return true
+	}
+	_, seen := r.visited.LoadOrStore(fileKey{t.dev, t.ino}, struct{}{})
+	return !seen
+}
+
+// followLink queues the directory the symlink at path points to for
+// clearing, with SymlinkFollow, and the link for removal after it. It
+// reports false if the link is to be removed as it is instead: it does
+// not lead to a directory, leads into the target, which is cleared
+// anyway, to another filesystem, to a directory already entered or to
+// a protected path, or t is already MaxSymlinkHops links deep.
+func (d *Deleter) followLink(r *run, t *dirTask, path string, entry os.DirEntry) bool {
+	if d.config.SymlinkPolicy != config.SymlinkFollow || d.config.Root != nil || t.hops >= d.config.MaxSymlinkHops {
+		return false
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+	dev, ino, ok := fileID(info)
+	if !ok || !r.devKnown || dev != r.dev {
+		return false
+	}
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil || isWithin(r.realRoot, real) {
+		return false
+	}
+	if _, protected := protectedBy(d.protected, real); protected {
+		return false
+	}
+	if _, seen := r.visited.LoadOrStore(fileKey{dev, ino}, struct{}{}); seen {
+		return false
+	}
+
+	child := newDirTask(path, t)
+	child.dev, child.ino = dev, ino
+	child.follow = true
+	child.hops = t.hops + 1
+	child.finish = func(*dirTask) {
+		if d.processFile(r, t.dir, path, entry) {
+			t.deferred.Store(true)
+		}
+	}
+	t.pending.Add(1)
+	d.queueDir(r, child)
+	return true
+}
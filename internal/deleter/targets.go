@@ -0,0 +1,119 @@
+package deleter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/yourusername/rmrf/internal/config"
+)
+
+var ErrNoMatch = errors.New("no files match pattern")
+
+// Expand resolves target arguments the way DeleteMany does, without
+// deleting anything, so callers can show what a run will touch. Arguments
+// that fail to expand are reported in the joined error.
+func (d *Deleter) Expand(args ...string) ([]string, error) {
+	var paths []string
+	var errs []error
+	for _, arg := range args {
+		matches, err := d.expandTarget(arg)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		paths = append(paths, matches...)
+	}
+	paths, err := d.selectTargets(context.Background(), paths)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return paths, errors.Join(errs...)
+}
+
+// selectTargets replaces paths with what is below them, under WithGitClean,
+// WithPresets and WithCacheDirsOnly; otherwise it returns them as they
+// are.
+func (d *Deleter) selectTargets(ctx context.Context, paths []string) ([]string, error) {
+	var err error
+	if d.config.GitClean != "" {
+		if paths, err = d.gitTargets(ctx, paths); err != nil {
+			return nil, err
+		}
+	}
+	if len(d.config.Presets) > 0 {
+		if paths, err = d.presetTargets(ctx, paths); err != nil {
+			return nil, err
+		}
+	}
+	if d.config.CacheDirsOnly {
+		if paths, err = d.findDirs(ctx, paths, func(path, _ string) bool { return hasCacheTag(path) }); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// expandTarget expands arg as a glob (with "**" support) if it contains a
+// metacharacter and globbing is enabled, and returns it unchanged
+// otherwise.
+func (d *Deleter) expandTarget(arg string) ([]string, error) {
+	if d.config.NoGlob || !hasMeta(arg) {
+		return []string{arg}, nil
+	}
+
+	matches, err := glob(arg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", arg, err)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoMatch, arg)
+	}
+	return matches, nil
+}
+
+// dedupeTargets makes paths canonical, see canonicalPath, and drops any
+// that repeat another target or lie inside one, since deleting the outer
+// target covers them. Targets are compared by that canonical form: a
+// target that is a symlink is only unlinked, so it covers nothing but
+// itself. Only with SymlinkFollow, which deletes what it points to as
+// well, are targets compared by their resolved form.
+func (d *Deleter) dedupeTargets(paths []string) ([]string, error) {
+	type target struct{ abs, resolved string }
+
+	targets := make([]target, 0, len(paths))
+	for _, path := range paths {
+		abs, err := canonicalPath(path)
+		if err != nil {
+			return nil, err
+		}
+		resolved := abs
+		if d.config.SymlinkPolicy == config.SymlinkFollow {
+			if r, err := filepath.EvalSymlinks(abs); err == nil {
+				resolved = r
+			}
+		}
+		targets = append(targets, target{abs, resolved})
+	}
+
+	roots := make([]string, 0, len(targets))
+	for i, t := range targets {
+		covered := false
+		for j, other := range targets {
+			if i == j || !isWithin(other.resolved, t.resolved) {
+				continue
+			}
+			// Of two identical targets keep the first; otherwise the outer one wins.
+			if other.resolved != t.resolved || j < i {
+				d.stats.AddWarning(fmt.Sprintf("skipping %s: covered by %s", t.abs, other.abs))
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			roots = append(roots, t.abs)
+		}
+	}
+	return roots, nil
+}
@@ -0,0 +1,24 @@
+package deleter
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/yourusername/rmrf/internal/config"
+)
+
+// entryTime returns the timestamp of info that field names.
+func entryTime(info os.FileInfo, field config.TimeField) time.Time {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return info.ModTime()
+	}
+	switch field {
+	case config.TimeAccessed:
+		return time.Unix(st.Atimespec.Unix())
+	case config.TimeChanged:
+		return time.Unix(st.Ctimespec.Unix())
+	}
+	return info.ModTime()
+}
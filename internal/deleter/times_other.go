@@ -0,0 +1,16 @@
+//go:build !linux && !darwin
+
+package deleter
+
+import (
+	"os"
+	"time"
+
+	"github.com/yourusername/rmrf/internal/config"
+)
+
+// entryTime returns the modification time of info; access and change
+// times are not available here.
+func entryTime(info os.FileInfo, field config.TimeField) time.Time {
+	return info.ModTime()
+}
@@ -0,0 +1,60 @@
+package deleter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yourusername/rmrf/internal/reporter"
+	"github.com/yourusername/rmrf/internal/trash"
+)
+
+// trashRoot moves a whole target into the trash in one step instead of
+// walking it, so it stays restorable as a single item. If there is no
+// trash for the target it warns and returns false, leaving the target to
+// be deleted permanently.
+func (d *Deleter) trashRoot(r *run) bool {
+	if _, err := os.Lstat(filepath.Join(r.root, ignoreFileName)); err == nil {
+		d.fail(r, fmt.Errorf("%s: %w", r.root, ErrTrashIgnore))
+		return true
+	}
+	item, err := trash.Item{Original: r.root}, error(nil)
+	if !d.config.DryRun {
+		item, err = d.fs.Trash(r.root)
+	}
+	if errors.Is(err, trash.ErrUnavailable) {
+		d.stats.AddWarning(fmt.Sprintf("%s: %v, deleting permanently instead", r.root, err))
+		return false
+	}
+
+	r.progress.AddTotal(1)
+	defer r.progress.Update(1)
+	if err != nil {
+		d.fail(r, err)
+		return true
+	}
+	d.stats.AddTrashed(item)
+	d.emit(reporter.EventTrashed, r.root)
+	if d.trashLog != nil {
+		if err := d.trashLog.Append(item); err != nil {
+			d.fail(r, err)
+		}
+	}
+	return true
+}
+
+// openTrashLog starts the operation log that makes a trash run reversible
+// with "rmrf restore <id>". Dry runs do not get one.
+func (d *Deleter) openTrashLog() error {
+	if !d.config.Trash || d.config.DryRun {
+		return nil
+	}
+	log, err := trash.NewLog()
+	if err != nil {
+		return err
+	}
+	d.trashLog = log
+	d.stats.OperationID = log.ID
+	return nil
+}
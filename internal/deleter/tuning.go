@@ -0,0 +1,92 @@
+package deleter
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/yourusername/rmrf/internal/config"
+)
+
+// fsThreads maps a local filesystem type to a default worker count for a
+// machine with the given number of CPUs. Memory-backed filesystems are
+// bounded by CPU, and local journaled ones gain a little from overlapping
+// metadata IO.
+var fsThreads = map[string]func(cpus int) int{
+	"tmpfs":     func(cpus int) int { return cpus * 4 },
+	"ext4":      func(cpus int) int { return cpus * 2 },
+	"xfs":       func(cpus int) int { return cpus * 2 },
+	"btrfs":     func(cpus int) int { return cpus },
+	"overlayfs": func(cpus int) int { return cpus },
+}
+
+// networkFS are the filesystem types config.ProfileAuto treats as
+// network filesystems.
+var networkFS = map[string]bool{
+	"nfs":   true,
+	"cifs":  true,
+	"smb2":  true,
+	"sshfs": true,
+}
+
+// networkThreads is the worker count of config.ProfileNetwork: removals
+// there mostly wait on round trips to the server, so many more of them
+// can be in flight than there are CPUs.
+func networkThreads(cpus int) int {
+	return min(max(cpus*8, 16), 64)
+}
+
+// Retry backoffs of the profiles. Files held open on a network
+// filesystem take longer to be let go of, see heldOpen.
+const (
+	localRetryBackoff   = 200 * time.Millisecond
+	networkRetryBackoff = time.Second
+)
+
+// tuning is how a run is set up for the filesystem of its targets.
+type tuning struct {
+	fs      string // detected filesystem type, if known
+	profile config.Profile
+	threads int
+	backoff time.Duration // before the first retry round
+}
+
+// tune returns the tuning for a run on path. The profile is the configured
+// one, or with config.ProfileAuto, the one the filesystem calls for. An
+// explicit MaxThreads or RetryBackoff always wins, and with
+// WithAdaptiveThreads the workers start from maxAdaptiveThreads; otherwise
+// the network profile has its own, and the local one sizes the workers to
+// the CPUs as the filesystem prefers, one per CPU if it is unknown.
+func (d *Deleter) tune(path string) tuning {
+	fs, _ := fsType(path)
+	t := tuning{fs: fs, profile: d.config.Profile}
+	if t.profile == config.ProfileAuto || t.profile == "" {
+		t.profile = config.ProfileLocal
+		if networkFS[fs] {
+			t.profile = config.ProfileNetwork
+		}
+	}
+
+	cpus := runtime.NumCPU()
+	switch tune, ok := fsThreads[fs]; {
+	case d.config.MaxThreads > 0:
+		t.threads = d.config.MaxThreads
+	case d.config.AdaptiveThreads:
+		t.threads = maxAdaptiveThreads
+	case t.profile == config.ProfileNetwork:
+		t.threads = networkThreads(cpus)
+	case ok:
+		t.threads = max(tune(cpus), 1)
+	default:
+		t.threads = cpus
+	}
+
+	switch {
+	case d.config.RetryBackoff > 0:
+		t.backoff = d.config.RetryBackoff
+	case t.profile == config.ProfileNetwork:
+		t.backoff = networkRetryBackoff
+	default:
+		t.backoff = localRetryBackoff
+	}
+	return t
+}
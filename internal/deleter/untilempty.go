@@ -0,0 +1,50 @@
+package deleter
+
+import (
+	"context"
+	"os"
+
+	"github.com/yourusername/rmrf/internal/reporter"
+)
+
+// appeared reports whether the directory at path, which could not be
+// removed, has entries that appeared in it during the pass, and if so
+// asks for another pass, with WithUntilEmpty. On the last pass, and
+// without it, the removal is left to fail.
+func (d *Deleter) appeared(path string) bool {
+	if d.config.UntilEmpty <= 1 || d.lastPass.Load() {
+		return false
+	}
+	dir, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer dir.Close()
+	if names, _ := dir.Readdirnames(1); len(names) == 0 {
+		return false
+	}
+	d.passAgain.Store(true)
+	return true
+}
+
+// anotherPass records the counts of pass, the given one, since before,
+// and reports whether to make another: entries appeared during it and
+// passes remain. After a pass that deleted nothing, the next is the last,
+// so what is still there gets reported rather than walked again.
+func (d *Deleter) anotherPass(ctx context.Context, pass int, before reporter.Snapshot) bool {
+	if d.config.UntilEmpty <= 1 {
+		return false
+	}
+	after := d.stats.Snapshot()
+	p := reporter.PassStats{
+		Files:  after.FilesDeleted - before.FilesDeleted,
+		Dirs:   after.DirsDeleted - before.DirsDeleted,
+		Errors: after.Errors - before.Errors,
+	}
+	d.stats.AddPass(p)
+	again := d.passAgain.Swap(false) && pass < d.config.UntilEmpty && ctx.Err() == nil && !d.quitting.Load()
+	if again && p.Files+p.Dirs == 0 {
+		d.lastPass.Store(true)
+	}
+	return again
+}
@@ -0,0 +1,277 @@
+//go:build linux
+
+package deleter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+// The parts of the io_uring ABI the uring engine uses. The syscall
+// numbers are the same on every architecture Go supports.
+const (
+	sysIOURingSetup = 425
+	sysIOURingEnter = 426
+
+	ioringOffSQRing = 0
+	ioringOffCQRing = 0x8000000
+	ioringOffSQEs   = 0x10000000
+
+	ioringEnterGetEvents = 1
+	ioringOpUnlinkat     = 36
+
+	uringEntries = 256
+)
+
+type uringSQRingOffsets struct {
+	head, tail, ringMask, ringEntries, flags, dropped, array, resv1 uint32
+	userAddr                                                        uint64
+}
+
+type uringCQRingOffsets struct {
+	head, tail, ringMask, ringEntries, overflow, cqes, flags, resv1 uint32
+	userAddr                                                        uint64
+}
+
+type uringParams struct {
+	sqEntries, cqEntries, flags, sqThreadCPU, sqThreadIdle, features, wqFD uint32
+	resv                                                                   [3]uint32
+	sqOff                                                                  uringSQRingOffsets
+	cqOff                                                                  uringCQRingOffsets
+}
+
+type uringSQE struct {
+	opcode      uint8
+	flags       uint8
+	ioprio      uint16
+	fd          int32
+	off         uint64
+	addr        uint64
+	len         uint32
+	opFlags     uint32
+	userData    uint64
+	bufIndex    uint16
+	personality uint16
+	spliceFDIn  int32
+	addr3       uint64
+	pad         uint64
+}
+
+type uringCQE struct {
+	userData uint64
+	res      int32
+	flags    uint32
+}
+
+// uringRequest is one unlinkat for the ring to run. name must stay
+// reachable, NUL-terminated, until done has been sent to.
+type uringRequest struct {
+	dirfd int
+	name  []byte
+	flags uint32
+	done  chan error
+}
+
+// errURingFailed is what requests get back if the ring itself stopped
+// working; they are then retried without it.
+var errURingFailed = errors.New("io_uring submission failed")
+
+// uring submits unlinkat calls through an io_uring. Workers hand requests
+// to a single goroutine that owns the ring; whatever has queued up by the
+// time it gets to them goes to the kernel in one io_uring_enter, so with
+// many workers unlinking at once the syscalls are shared between them.
+type uring struct {
+	fd             int
+	sqRing, cqRing []byte
+	sqes           []byte
+	sqTail, sqMask *uint32
+	sqArray        unsafe.Pointer
+	cqHead, cqTail *uint32
+	cqMask         uint32
+	cqes           unsafe.Pointer
+	entries        uint32
+
+	requests chan *uringRequest
+	stopped  chan struct{}
+	failed   bool // owned by loop
+}
+
+// newURing sets up a ring, failing if the kernel or a seccomp policy does
+// not allow io_uring, or if the kernel is too old for IORING_OP_UNLINKAT.
+func newURing() (*uring, error) {
+	var p uringParams
+	fd, _, errno := syscall.Syscall(sysIOURingSetup, uringEntries, uintptr(unsafe.Pointer(&p)), 0)
+	if errno != 0 {
+		return nil, os.NewSyscallError("io_uring_setup", errno)
+	}
+	u := &uring{fd: int(fd), entries: p.sqEntries}
+
+	mmap := func(offset int64, size uint32) ([]byte, error) {
+		return syscall.Mmap(u.fd, offset, int(size), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED|syscall.MAP_POPULATE)
+	}
+	var err error
+	if u.sqRing, err = mmap(ioringOffSQRing, p.sqOff.array+p.sqEntries*4); err == nil {
+		if u.cqRing, err = mmap(ioringOffCQRing, p.cqOff.cqes+p.cqEntries*uint32(unsafe.Sizeof(uringCQE{}))); err == nil {
+			u.sqes, err = mmap(ioringOffSQEs, p.sqEntries*uint32(unsafe.Sizeof(uringSQE{})))
+		}
+	}
+	if err != nil {
+		u.unmap()
+		return nil, os.NewSyscallError("mmap", err)
+	}
+
+	sq, cq := unsafe.Pointer(&u.sqRing[0]), unsafe.Pointer(&u.cqRing[0])
+	u.sqTail = (*uint32)(unsafe.Add(sq, p.sqOff.tail))
+	u.sqMask = (*uint32)(unsafe.Add(sq, p.sqOff.ringMask))
+	u.sqArray = unsafe.Add(sq, p.sqOff.array)
+	u.cqHead = (*uint32)(unsafe.Add(cq, p.cqOff.head))
+	u.cqTail = (*uint32)(unsafe.Add(cq, p.cqOff.tail))
+	u.cqMask = *(*uint32)(unsafe.Add(cq, p.cqOff.ringMask))
+	u.cqes = unsafe.Add(cq, p.cqOff.cqes)
+
+	u.requests = make(chan *uringRequest, p.sqEntries)
+	u.stopped = make(chan struct{})
+	go u.loop()
+
+	// An empty name never exists, so a kernel that knows the opcode says
+	// ENOENT, and one that doesn't says EINVAL.
+	if err := u.do(-1, "", 0); err != syscall.ENOENT {
+		u.close()
+		return nil, errors.New("io_uring: unlinkat not supported")
+	}
+	return u, nil
+}
+
+// unlinkAt removes name in dir through the ring, like removeAt.
+func (u *uring) unlinkAt(dir *os.File, name string, isDir bool) error {
+	var flags uint32
+	if isDir {
+		flags = atRemoveDir
+	}
+	err := u.do(int(dir.Fd()), name, flags)
+	if err == errURingFailed {
+		return removeAt(dir, name, isDir)
+	}
+	if err != nil {
+		return &os.PathError{Op: "remove", Path: filepath.Join(dir.Name(), name), Err: err}
+	}
+	return nil
+}
+
+// do runs one unlinkat through the ring and waits for its result.
+func (u *uring) do(dirfd int, name string, flags uint32) error {
+	req := &uringRequest{
+		dirfd: dirfd,
+		name:  append([]byte(name), 0),
+		flags: flags,
+		done:  make(chan error, 1),
+	}
+	u.requests <- req
+	return <-req.done
+}
+
+// loop owns the ring: it takes whatever requests are waiting, submits
+// them together and hands back each result.
+func (u *uring) loop() {
+	defer close(u.stopped)
+	inflight := make([]*uringRequest, u.entries)
+	for req := range u.requests {
+		if u.failed {
+			req.done <- errURingFailed
+			continue
+		}
+
+		n := uint32(0)
+		for req != nil {
+			u.prepare(n, req)
+			inflight[n] = req
+			n++
+			req = nil
+			if n < u.entries {
+				select {
+				case req = <-u.requests:
+				default:
+				}
+			}
+		}
+		atomic.StoreUint32(u.sqTail, atomic.LoadUint32(u.sqTail)+n)
+
+		toSubmit, pending := n, n
+		for pending > 0 {
+			submitted, _, errno := syscall.Syscall6(sysIOURingEnter, uintptr(u.fd), uintptr(toSubmit), 1, ioringEnterGetEvents, 0, 0)
+			switch errno {
+			case 0:
+				toSubmit -= uint32(submitted)
+			case syscall.EINTR:
+			default:
+				// Entries may be left in the ring half submitted, so it
+				// can't be trusted again.
+				u.failed = true
+				for i, req := range inflight {
+					if req != nil {
+						req.done <- errURingFailed
+						inflight[i] = nil
+					}
+				}
+				pending = 0
+				continue
+			}
+			pending -= u.reap(inflight)
+		}
+	}
+}
+
+// prepare fills submission slot i, counting from the current tail, with
+// req.
+func (u *uring) prepare(i uint32, req *uringRequest) {
+	idx := (atomic.LoadUint32(u.sqTail) + i) & *u.sqMask
+	sqe := (*uringSQE)(unsafe.Add(unsafe.Pointer(&u.sqes[0]), uintptr(idx)*unsafe.Sizeof(uringSQE{})))
+	*sqe = uringSQE{
+		opcode:   ioringOpUnlinkat,
+		fd:       int32(req.dirfd),
+		addr:     uint64(uintptr(unsafe.Pointer(&req.name[0]))),
+		opFlags:  req.flags,
+		userData: uint64(i),
+	}
+	*(*uint32)(unsafe.Add(u.sqArray, uintptr(idx)*4)) = idx
+}
+
+// reap hands back the results of completed requests and returns how many
+// there were.
+func (u *uring) reap(inflight []*uringRequest) uint32 {
+	var n uint32
+	head := atomic.LoadUint32(u.cqHead)
+	for tail := atomic.LoadUint32(u.cqTail); head != tail; head++ {
+		cqe := (*uringCQE)(unsafe.Add(u.cqes, uintptr(head&u.cqMask)*unsafe.Sizeof(uringCQE{})))
+		req := inflight[cqe.userData]
+		inflight[cqe.userData] = nil
+		if cqe.res < 0 {
+			req.done <- syscall.Errno(-cqe.res)
+		} else {
+			req.done <- nil
+		}
+		n++
+	}
+	atomic.StoreUint32(u.cqHead, head)
+	return n
+}
+
+// close stops the ring once the requests already handed to it are done.
+func (u *uring) close() {
+	close(u.requests)
+	<-u.stopped
+	u.unmap()
+}
+
+func (u *uring) unmap() {
+	for _, m := range [][]byte{u.sqes, u.cqRing, u.sqRing} {
+		if m != nil {
+			syscall.Munmap(m)
+		}
+	}
+	syscall.Close(u.fd)
+}
@@ -0,0 +1,20 @@
+//go:build !linux
+
+package deleter
+
+import (
+	"errors"
+	"os"
+)
+
+type uring struct{}
+
+func newURing() (*uring, error) {
+	return nil, errors.New("io_uring is only available on Linux")
+}
+
+func (*uring) unlinkAt(dir *os.File, name string, isDir bool) error {
+	return removeAt(dir, name, isDir)
+}
+
+func (*uring) close() {}
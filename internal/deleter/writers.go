@@ -0,0 +1,86 @@
+package deleter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// dirWatcher reports entries created in the directories added to it. It
+// is implemented with inotify on Linux only.
+type dirWatcher interface {
+	add(path string) error
+	close()
+}
+
+// writerWatch notices entries that another process creates in the
+// targets while they are being deleted, see WithWatchWrites.
+type writerWatch struct {
+	dirs  dirWatcher
+	limit sync.Once
+
+	mu      sync.Mutex
+	created int
+	first   string
+}
+
+// startWriterWatch watches each directory of the targets, from when it is
+// opened until it is removed, for entries created in it, with
+// WithWatchWrites. Nothing the run itself does creates one. With
+// WithAbortOnWrites, the first cancels the run. The returned stop func
+// reports them as a warning, or returns ErrActiveWriter if the run was
+// cancelled for them.
+func (d *Deleter) startWriterWatch(cancel context.CancelFunc) (stop func() error, err error) {
+	if !d.config.WatchWrites || d.config.DryRun {
+		return func() error { return nil }, nil
+	}
+	w := &writerWatch{}
+	w.dirs, err = newDirWatcher(func(path string) {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if w.created++; w.first == "" {
+			w.first = path
+			if d.config.AbortOnWrites {
+				cancel()
+			}
+		}
+	})
+	if errors.Is(err, errors.ErrUnsupported) && !d.config.AbortOnWrites {
+		d.stats.AddWarning("watching for other writers is not supported on this platform")
+		return func() error { return nil }, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("watching for other writers: %w", err)
+	}
+	d.writers = w
+
+	return func() error {
+		d.writers = nil
+		w.dirs.close()
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		switch {
+		case w.created == 0:
+			return nil
+		case d.config.AbortOnWrites:
+			return fmt.Errorf("%w: %s", ErrActiveWriter, w.first)
+		}
+		d.stats.AddWarning(fmt.Sprintf("another process created %d entries in the targets while they were being deleted, the first %s; it may still be running", w.created, w.first))
+		return nil
+	}, nil
+}
+
+// watchDir starts watching the directory at path for other writers, once
+// it is open and before it is read.
+func (d *Deleter) watchDir(path string) {
+	w := d.writers
+	if w == nil {
+		return
+	}
+	if err := w.dirs.add(path); err != nil {
+		w.limit.Do(func() {
+			d.stats.AddWarning("not every directory is watched for other writers: " + err.Error())
+		})
+	}
+}
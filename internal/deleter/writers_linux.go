@@ -0,0 +1,83 @@
+package deleter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// writerEvents are the inotify events of an entry appearing in a watched
+// directory.
+const writerEvents = syscall.IN_CREATE | syscall.IN_MOVED_TO | syscall.IN_ONLYDIR
+
+// inotifyWatcher is the inotify dirWatcher.
+type inotifyWatcher struct {
+	fd   int // not file.Fd(), which would make reads blocking
+	file *os.File
+	done sync.WaitGroup
+
+	mu   sync.Mutex
+	dirs map[int32]string
+}
+
+// newDirWatcher returns a dirWatcher calling created with the path of
+// each entry created in a watched directory, from its own goroutine.
+func newDirWatcher(created func(path string)) (dirWatcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_NONBLOCK | syscall.IN_CLOEXEC)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	w := &inotifyWatcher{fd: fd, file: os.NewFile(uintptr(fd), "inotify"), dirs: make(map[int32]string)}
+	w.done.Go(func() { w.read(created) })
+	return w, nil
+}
+
+func (w *inotifyWatcher) add(path string) error {
+	wd, err := syscall.InotifyAddWatch(w.fd, path, writerEvents)
+	if errors.Is(err, syscall.ENOSPC) {
+		return errors.New("inotify watch limit reached (fs.inotify.max_user_watches)")
+	}
+	if err != nil {
+		return &os.PathError{Op: "inotify_add_watch", Path: path, Err: err}
+	}
+	w.mu.Lock()
+	w.dirs[int32(wd)] = path
+	w.mu.Unlock()
+	return nil
+}
+
+// read reports the events of w until it is closed. A watch goes away by
+// itself with its directory, with IN_IGNORED.
+func (w *inotifyWatcher) read(created func(path string)) {
+	buf := make([]byte, 64<<10)
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			return
+		}
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			name := buf[off+syscall.SizeofInotifyEvent : off+syscall.SizeofInotifyEvent+int(ev.Len)]
+			off += syscall.SizeofInotifyEvent + int(ev.Len)
+
+			w.mu.Lock()
+			dir, ok := w.dirs[ev.Wd]
+			if ev.Mask&syscall.IN_IGNORED != 0 {
+				delete(w.dirs, ev.Wd)
+			}
+			w.mu.Unlock()
+			if ok && ev.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+				created(filepath.Join(dir, strings.TrimRight(string(name), "\x00")))
+			}
+		}
+	}
+}
+
+func (w *inotifyWatcher) close() {
+	w.file.Close()
+	w.done.Wait()
+}
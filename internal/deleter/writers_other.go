@@ -0,0 +1,10 @@
+//go:build !linux
+
+package deleter
+
+import "errors"
+
+// newDirWatcher is only implemented with inotify, on Linux.
+func newDirWatcher(created func(path string)) (dirWatcher, error) {
+	return nil, errors.ErrUnsupported
+}
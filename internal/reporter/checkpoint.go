@@ -0,0 +1,43 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Checkpoint is the saved traversal state of an unfinished run, see
+// config.WithCheckpoint.
+type Checkpoint struct {
+	Args      []string  `json:"args"`      // the command line, to resume with
+	Dir       string    `json:"dir"`       // the working directory it was run in
+	Targets   []string  `json:"targets"`   // as the run resolved them
+	Completed []string  `json:"completed"` // subtrees done with, all that is left in them kept
+	Pending   []string  `json:"pending"`   // directories queued or being cleared
+	Updated   time.Time `json:"updated"`
+}
+
+// ReadCheckpoint reads the checkpoint at path.
+func ReadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Checkpoint
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// WriteFile writes the checkpoint to path, atomically, see
+// writeFileAtomic.
+func (c *Checkpoint) WriteFile(path string) error {
+	c.Updated = time.Now()
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
@@ -0,0 +1,95 @@
+package reporter
+
+import (
+	"encoding/json"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErasureEntry is one path a run deleted, as recorded in an
+// ErasureReport. SHA256 is the hex digest of a regular file's contents
+// taken just before it was removed, if hashing was asked for.
+type ErasureEntry struct {
+	Path      string    `json:"path"`
+	Type      string    `json:"type"` // "file", "dir", "symlink" or "special"
+	Size      int64     `json:"size"`
+	Mtime     time.Time `json:"mtime"`
+	DeletedAt time.Time `json:"deletedAt"`
+	SHA256    string    `json:"sha256,omitempty"`
+}
+
+// ErasureReport is a proof-of-erasure manifest: who deleted what, where
+// and when. Add is not safe for concurrent use; the deleter serializes
+// its callbacks.
+type ErasureReport struct {
+	Tool     string         `json:"tool"`
+	Operator string         `json:"operator"`
+	UID      int            `json:"uid"`
+	Hostname string         `json:"hostname"`
+	Started  time.Time      `json:"started"`
+	Finished time.Time      `json:"finished"`
+	Errors   int            `json:"errors"`
+	Entries  []ErasureEntry `json:"entries"`
+}
+
+// NewErasureReport starts a report for a run beginning now, by the
+// current user on this host. Under sudo the operator is the invoking
+// user, as "alice (via sudo)".
+func NewErasureReport() *ErasureReport {
+	r := &ErasureReport{Tool: "rmrf", UID: os.Getuid(), Started: time.Now()}
+	if u, err := user.Current(); err == nil {
+		r.Operator = u.Username
+	}
+	if sudoer := os.Getenv("SUDO_USER"); sudoer != "" {
+		r.Operator = sudoer + " (via sudo)"
+	}
+	r.Hostname, _ = os.Hostname()
+	return r
+}
+
+// Add records a deleted entry.
+func (r *ErasureReport) Add(e ErasureEntry) {
+	r.Entries = append(r.Entries, e)
+}
+
+// WriteFile finishes the report and writes it to path as indented JSON,
+// entries sorted by path so the same run always gives the same bytes to
+// sign. The file appears whole or not at all, see writeFileAtomic.
+func (r *ErasureReport) WriteFile(path string, errors int) error {
+	r.Finished = time.Now()
+	r.Errors = errors
+	slices.SortFunc(r.Entries, func(a, b ErasureEntry) int { return strings.Compare(a.Path, b.Path) })
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// writeFileAtomic writes data to path so that it appears whole or not at
+// all: it is written and synced under a temporary name in the same
+// directory, then renamed.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
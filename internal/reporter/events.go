@@ -0,0 +1,71 @@
+package reporter
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Event types, as they appear in the "type" field.
+const (
+	EventFileDeleted = "file-deleted"
+	EventDirDeleted  = "dir-deleted"
+	EventTrashed     = "trashed"
+	EventSkipped     = "skipped"
+	EventError       = "error"
+	EventProgress    = "progress"
+	EventDone        = "done"
+)
+
+// Event is one entry in the live event stream of a run.
+type Event struct {
+	Type      string    `json:"type"`
+	Time      time.Time `json:"time"`
+	Path      string    `json:"path,omitempty"`
+	Reason    string    `json:"reason,omitempty"` // why a path was skipped
+	Error     string    `json:"error,omitempty"`
+	Processed int       `json:"processed,omitempty"`
+	Total     int       `json:"total,omitempty"`
+	Elapsed   float64   `json:"elapsed,omitempty"` // seconds, on done
+}
+
+// NewEventJSONWriter returns an event callback that writes each event to
+// w as one JSON object per line. It is safe for concurrent use.
+func NewEventJSONWriter(w io.Writer) func(Event) {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	return func(e Event) {
+		if e.Time.IsZero() {
+			e.Time = time.Now()
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		enc.Encode(e)
+	}
+}
+
+// EventReporter turns progress into events, at most one progress event
+// per interval plus a final done event.
+type EventReporter struct {
+	emit     func(Event)
+	interval time.Duration
+	last     time.Time
+}
+
+func NewEventReporter(emit func(Event), interval time.Duration) *EventReporter {
+	return &EventReporter{emit: emit, interval: interval}
+}
+
+func (e *EventReporter) Update(processed, total int) {
+	now := time.Now()
+	if now.Sub(e.last) < e.interval {
+		return
+	}
+	e.last = now
+	e.emit(Event{Type: EventProgress, Time: now, Processed: processed, Total: total})
+}
+
+func (e *EventReporter) Complete(elapsed time.Duration) {
+	e.emit(Event{Type: EventDone, Elapsed: elapsed.Seconds()})
+}
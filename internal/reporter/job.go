@@ -0,0 +1,171 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Job states, as they appear in the "state" field of a JobState.
+const (
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// jobUpdateInterval is how often a Job rewrites its state file while the
+// run progresses.
+const jobUpdateInterval = time.Second
+
+// JobState is the content of the state file of a background run.
+type JobState struct {
+	ID        string          `json:"id"`
+	PID       int             `json:"pid"`
+	Targets   []string        `json:"targets"`
+	State     string          `json:"state"`
+	Started   time.Time       `json:"started"`
+	Updated   time.Time       `json:"updated"`
+	Processed int             `json:"processed"`
+	Total     int             `json:"total"`
+	Rate      float64         `json:"rate"`
+	ETA       float64         `json:"eta"`             // seconds
+	Error     string          `json:"error,omitempty"` // why the run failed
+	Stats     json.RawMessage `json:"stats,omitempty"` // as --format json prints them, once finished
+}
+
+// JobDir is where the state files of background runs are kept:
+// $XDG_STATE_HOME/rmrf/jobs, defaulting to ~/.local/state/rmrf/jobs.
+func JobDir() (string, error) {
+	if state := os.Getenv("XDG_STATE_HOME"); state != "" {
+		return filepath.Join(state, "rmrf", "jobs"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "rmrf", "jobs"), nil
+}
+
+// NewJobID returns the ID and state file path of a new background run.
+// Like operation IDs, it combines the time and process ID.
+func NewJobID() (id, path string, err error) {
+	dir, err := JobDir()
+	if err != nil {
+		return "", "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", "", err
+	}
+	id = fmt.Sprintf("%s-%d", time.Now().Format("20060102T150405"), os.Getpid())
+	return id, filepath.Join(dir, id+".json"), nil
+}
+
+// JobPath resolves a job ID or a state file path to the state file.
+func JobPath(idOrPath string) (string, error) {
+	if strings.HasSuffix(idOrPath, ".json") || strings.ContainsRune(idOrPath, filepath.Separator) {
+		return idOrPath, nil
+	}
+	dir, err := JobDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, idOrPath+".json"), nil
+}
+
+// ReadJob reads the state file at path.
+func ReadJob(path string) (JobState, error) {
+	var state JobState
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("%s: %w", path, err)
+	}
+	return state, nil
+}
+
+// Job keeps the state file of a run up to date. It is a Reporter, which
+// rewrites the file at most once a second, and Finish records the outcome.
+// Every write replaces the file atomically, so readers never see half of
+// one.
+type Job struct {
+	path string
+
+	mu    sync.Mutex
+	state JobState
+	last  time.Time
+}
+
+// OpenJob starts keeping the state of the run of this process in path,
+// and writes it once. If the file is already there, written by the
+// process that started this one, its ID, targets and start time are kept;
+// otherwise the ID is the file name without ".json".
+func OpenJob(path string, targets []string) (*Job, error) {
+	state, err := ReadJob(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		state = JobState{
+			ID:      strings.TrimSuffix(filepath.Base(path), ".json"),
+			Targets: targets,
+			Started: time.Now(),
+		}
+	}
+	state.PID, state.State = os.Getpid(), JobRunning
+	j := &Job{path: path, state: state, last: time.Now()}
+	return j, j.write()
+}
+
+// ID returns the job's ID.
+func (j *Job) ID() string {
+	return j.state.ID
+}
+
+func (j *Job) Update(processed, total int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	j.state.Processed, j.state.Total = processed, total
+	if now.Sub(j.last) < jobUpdateInterval {
+		return
+	}
+	j.last = now
+	rate, eta := rateAndETA(processed, total, now.Sub(j.state.Started))
+	j.state.Rate, j.state.ETA = rate, eta.Seconds()
+	j.write()
+}
+
+func (j *Job) Complete(time.Duration) {}
+
+// Finish records the final stats of the run, and err if it failed.
+func (j *Job) Finish(stats *Stats, err error) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.state.State, j.state.ETA = JobDone, 0
+	if err != nil || stats != nil && len(stats.Errors) > 0 {
+		j.state.State = JobFailed
+	}
+	if err != nil {
+		j.state.Error = err.Error()
+	}
+	if stats != nil {
+		j.state.Stats = json.RawMessage(stats.JSON())
+	}
+	return j.write()
+}
+
+// write saves the state; j.mu must be held or j not yet shared.
+func (j *Job) write() error {
+	j.state.Updated = time.Now()
+	data, err := json.MarshalIndent(j.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(j.path, append(data, '\n'))
+}
@@ -0,0 +1,269 @@
+package reporter
+
+import (
+	"bufio"
+	"container/heap"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// manifestChunk is how many bytes of records ManifestHasher holds in
+// memory before it sorts them and spills them to a temporary file.
+var manifestChunk = 16 << 20
+
+// ManifestHasher builds a digest over the set of deleted entries: the
+// SHA-256 of the sorted manifest, so it does not depend on the order in
+// which concurrent workers report entries. Each (path, size) record is
+// written as "path\x00size\n", and records are sorted bytewise: for paths
+// without newlines, what
+//
+//	LC_ALL=C sort | sha256sum
+//
+// computes over a manifest in that form. Records are not all buffered:
+// past manifestChunk bytes they are sorted and spilled to a temporary
+// file, and Sum merges those runs.
+type ManifestHasher struct {
+	mu    sync.Mutex
+	chunk []string
+	size  int
+	runs  []*os.File
+}
+
+// Add records one deleted entry.
+func (h *ManifestHasher) Add(path string, size int64) {
+	record := path + "\x00" + strconv.FormatInt(size, 10) + "\n"
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.chunk = append(h.chunk, record)
+	if h.size += len(record); h.size >= manifestChunk {
+		// Should spilling fail, the records stay in memory.
+		if run, err := spillRun(h.chunk); err == nil {
+			h.runs = append(h.runs, run)
+			h.chunk, h.size = nil, 0
+		}
+	}
+}
+
+// Sum returns the digest of every record added so far as lowercase hex.
+// The records are kept, merged into one run, so a later Sum covers them
+// as well as those added since.
+func (h *ManifestHasher) Sum() (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	slices.Sort(h.chunk)
+	var merged runHeap
+	for _, run := range h.runs {
+		if _, err := run.Seek(0, io.SeekStart); err != nil {
+			return "", err
+		}
+		r := &runReader{r: bufio.NewReader(run)}
+		if err := r.next(); err != nil {
+			return "", err
+		}
+		if r.ok {
+			merged = append(merged, r)
+		}
+	}
+	if len(h.chunk) > 0 {
+		merged = append(merged, &runReader{chunk: h.chunk[1:], record: h.chunk[0], ok: true})
+	}
+	heap.Init(&merged)
+
+	out, err := newRunFile()
+	if err != nil {
+		return "", err
+	}
+	w := bufio.NewWriter(out)
+	digest := sha256.New()
+	for len(merged) > 0 {
+		r := merged[0]
+		io.WriteString(digest, r.record)
+		if err := writeRecord(w, r.record); err != nil {
+			closeRunFile(out)
+			return "", err
+		}
+		if err := r.next(); err != nil {
+			closeRunFile(out)
+			return "", err
+		}
+		if r.ok {
+			heap.Fix(&merged, 0)
+		} else {
+			heap.Pop(&merged)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		closeRunFile(out)
+		return "", err
+	}
+
+	for _, run := range h.runs {
+		closeRunFile(run)
+	}
+	h.runs, h.chunk, h.size = []*os.File{out}, nil, 0
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
+
+// spillRun sorts records and writes them to a new temporary file.
+func spillRun(records []string) (*os.File, error) {
+	slices.Sort(records)
+	f, err := newRunFile()
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(f)
+	for _, record := range records {
+		if err := writeRecord(w, record); err != nil {
+			closeRunFile(f)
+			return nil, err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		closeRunFile(f)
+		return nil, err
+	}
+	return f, nil
+}
+
+// newRunFile creates a temporary file for a run of records. It is unlinked
+// straight away where the system allows that of an open file, so it is
+// gone however the process ends.
+func newRunFile() (*os.File, error) {
+	f, err := os.CreateTemp("", "rmrf-manifest-*")
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(f.Name())
+	return f, nil
+}
+
+func closeRunFile(f *os.File) {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// writeRecord writes record to a run, prefixed by its length, as paths
+// may hold newlines.
+func writeRecord(w *bufio.Writer, record string) error {
+	if _, err := w.Write(binary.AppendUvarint(nil, uint64(len(record)))); err != nil {
+		return err
+	}
+	_, err := w.WriteString(record)
+	return err
+}
+
+// runReader yields the records of one sorted run, from a file or, for the
+// records still in memory, from chunk.
+type runReader struct {
+	r      *bufio.Reader
+	chunk  []string
+	record string
+	ok     bool
+}
+
+// next advances to the next record; ok is false once there is none.
+func (r *runReader) next() error {
+	if r.r == nil {
+		r.ok = len(r.chunk) > 0
+		if r.ok {
+			r.record, r.chunk = r.chunk[0], r.chunk[1:]
+		}
+		return nil
+	}
+	n, err := binary.ReadUvarint(r.r)
+	if errors.Is(err, io.EOF) {
+		r.ok = false
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r.r, buf); err != nil {
+		return err
+	}
+	r.record, r.ok = string(buf), true
+	return nil
+}
+
+// runHeap orders runs by their current record, for a k-way merge.
+type runHeap []*runReader
+
+func (h runHeap) Len() int           { return len(h) }
+func (h runHeap) Less(i, j int) bool { return h[i].record < h[j].record }
+func (h runHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *runHeap) Push(x any)        { *h = append(*h, x.(*runReader)) }
+func (h *runHeap) Pop() any {
+	old := *h
+	r := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return r
+}
+
+// Manifest statuses, as they appear in the status column.
+const (
+	StatusDeleted = "deleted"
+	StatusSkipped = "skipped"
+	StatusError   = "error"
+)
+
+// ManifestRow is what became of one entry a run processed. Detail is why
+// a skipped entry was kept, or the error. Type, Size and Mtime are empty
+// when the entry could not be stat'ed.
+type ManifestRow struct {
+	Path   string
+	Type   string // "file", "dir", "symlink" or "special"
+	Size   int64
+	Mtime  time.Time
+	Status string
+	Detail string
+}
+
+// ManifestCSV writes ManifestRows as CSV, after a header row. Add is safe
+// for concurrent use; call Flush once the run is over.
+type ManifestCSV struct {
+	mu sync.Mutex
+	w  *csv.Writer
+}
+
+// NewManifestCSV starts a CSV manifest on w.
+func NewManifestCSV(w io.Writer) *ManifestCSV {
+	m := &ManifestCSV{w: csv.NewWriter(w)}
+	m.w.Write([]string{"path", "type", "size", "mtime", "status", "detail"})
+	return m
+}
+
+// Add writes one row.
+func (m *ManifestCSV) Add(row ManifestRow) {
+	record := []string{row.Path, row.Type, "", "", row.Status, row.Detail}
+	if row.Type != "" {
+		record[2] = strconv.FormatInt(row.Size, 10)
+	}
+	if !row.Mtime.IsZero() {
+		record[3] = row.Mtime.UTC().Format(time.RFC3339)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.w.Write(record)
+}
+
+// Flush writes out any buffered rows and returns the first error writing
+// the manifest met.
+func (m *ManifestCSV) Flush() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.w.Flush()
+	return m.w.Error()
+}
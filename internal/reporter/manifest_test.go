@@ -0,0 +1,95 @@
+package reporter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// sortedManifestSum is what an auditor computes over a manifest: the
+// SHA-256 of its "path\x00size\n" records, sorted.
+func sortedManifestSum(paths []string, sizes map[string]int64) string {
+	records := make([]string, 0, len(paths))
+	for _, p := range paths {
+		records = append(records, fmt.Sprintf("%s\x00%d\n", p, sizes[p]))
+	}
+	slices.Sort(records)
+	sum := sha256.Sum256([]byte(strings.Join(records, "")))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestManifestHasher(t *testing.T) {
+	defer func(n int) { manifestChunk = n }(manifestChunk)
+
+	paths := []string{"a", "a/b", "a\nb", "a b", "ab", "é"}
+	sizes := make(map[string]int64)
+	for i := range 500 {
+		paths = append(paths, fmt.Sprintf("dir%d/file%d", i%7, i))
+	}
+	for i, p := range paths {
+		sizes[p] = int64(i * 37)
+	}
+	want := sortedManifestSum(paths, sizes)
+
+	for _, chunk := range []int{1 << 20, 100, 1} {
+		manifestChunk = chunk
+		for range 3 {
+			shuffled := slices.Clone(paths)
+			rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+			var h ManifestHasher
+			var wg sync.WaitGroup
+			for w := range 4 {
+				wg.Go(func() {
+					for i := w; i < len(shuffled); i += 4 {
+						h.Add(shuffled[i], sizes[shuffled[i]])
+					}
+				})
+			}
+			wg.Wait()
+			got, err := h.Sum()
+			if err != nil {
+				t.Fatalf("chunk %d: Sum: %v", chunk, err)
+			}
+			if got != want {
+				t.Errorf("chunk %d: Sum() = %s, want %s", chunk, got, want)
+			}
+		}
+	}
+}
+
+func TestManifestHasherAccumulates(t *testing.T) {
+	defer func(n int) { manifestChunk = n }(manifestChunk)
+	manifestChunk = 10
+
+	var h ManifestHasher
+	h.Add("b", 2)
+	h.Add("c", 3)
+	if _, err := h.Sum(); err != nil {
+		t.Fatal(err)
+	}
+	h.Add("a", 1)
+	got, err := h.Sum()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := sortedManifestSum([]string{"a", "b", "c"}, map[string]int64{"a": 1, "b": 2, "c": 3})
+	if got != want {
+		t.Errorf("Sum() = %s, want %s", got, want)
+	}
+
+	// Entries that differ only in how path and size split must not collide.
+	var x, y ManifestHasher
+	x.Add("a1", 2)
+	y.Add("a", 12)
+	sx, _ := x.Sum()
+	sy, _ := y.Sum()
+	if sx == sy {
+		t.Errorf("(a1, 2) and (a, 12) hash alike: %s", sx)
+	}
+}
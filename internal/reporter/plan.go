@@ -0,0 +1,44 @@
+package reporter
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// PlanEntry describes one file or directory a run deletes (or, in a dry
+// run, would delete). Device and inode identify the exact filesystem
+// object so a later run can tell whether the path was replaced.
+type PlanEntry struct {
+	Path  string    `json:"path"`
+	Type  string    `json:"type"` // "file" or "dir"
+	Size  int64     `json:"size"`
+	Mtime time.Time `json:"mtime"`
+	Dev   uint64    `json:"dev,omitempty"`
+	Inode uint64    `json:"inode,omitempty"`
+}
+
+// NewPlanJSONWriter returns a plan callback that writes each entry to w as
+// one JSON object per line.
+func NewPlanJSONWriter(w io.Writer) func(PlanEntry) {
+	enc := json.NewEncoder(w)
+	return func(e PlanEntry) {
+		enc.Encode(e)
+	}
+}
+
+// ReadPlan parses a plan written by NewPlanJSONWriter. An empty plan
+// gives an empty, not a nil, slice.
+func ReadPlan(r io.Reader) ([]PlanEntry, error) {
+	entries := []PlanEntry{}
+	dec := json.NewDecoder(r)
+	for {
+		var e PlanEntry
+		if err := dec.Decode(&e); err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return entries, err
+		}
+		entries = append(entries, e)
+	}
+}
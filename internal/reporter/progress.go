@@ -1,37 +1,106 @@
 package reporter
 
 import (
-	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// repaintInterval caps how often progress is rendered, so that deleting
+// millions of small files isn't slowed down by writing to the terminal.
+const repaintInterval = 100 * time.Millisecond
+
+// ProgressReporter tracks how far a deletion has got. Workers only bump
+// atomic counters; a separate goroutine renders them through a Reporter at
+// most every repaintInterval. Complete must be called to stop it.
 type ProgressReporter struct {
-	Total     int
-	Processed int
 	startTime time.Time
-	mu        sync.Mutex
+	out       Reporter
+
+	processed  atomic.Int64
+	discovered atomic.Int64 // entries actually seen during traversal
+	estimate   atomic.Int64 // rough seed for the total, see SetEstimate
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
 }
 
-func NewProgressReporter(total int) *ProgressReporter {
-	return &ProgressReporter{
-		Total:     total,
+// NewProgressReporter starts tracking progress towards total, rendering
+// through out. A nil out discards progress.
+func NewProgressReporter(total int, out Reporter) *ProgressReporter {
+	if out == nil {
+		out = NoopReporter{}
+	}
+	p := &ProgressReporter{
 		startTime: time.Now(),
+		out:       out,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
 	}
+	p.estimate.Store(int64(total))
+
+	if _, noop := out.(NoopReporter); noop {
+		close(p.done)
+	} else {
+		go p.render()
+	}
+	return p
+}
+
+// SetEstimate seeds the total with an approximate entry count. The
+// estimate is used only while it exceeds the number of entries actually
+// discovered.
+func (p *ProgressReporter) SetEstimate(n int) {
+	p.estimate.Store(int64(n))
 }
 
+// AddTotal records n newly discovered entries.
+func (p *ProgressReporter) AddTotal(n int) {
+	p.discovered.Add(int64(n))
+}
+
+// Update records count more entries as processed.
 func (p *ProgressReporter) Update(count int) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	p.Processed += count
-	
-	elapsed := time.Since(p.startTime)
-	rate := float64(p.Processed) / elapsed.Seconds()
-	remaining := float64(p.Total-p.Processed) / rate
-	
-	fmt.Printf("\rProgress: %d/%d (%.2f/s, ETA: %.1fs)", 
-		p.Processed, p.Total, rate, remaining)
+	p.processed.Add(int64(count))
+}
+
+// Counts returns the entries processed so far and the best current guess
+// at the total.
+func (p *ProgressReporter) Counts() (processed, total int) {
+	processed = int(p.processed.Load())
+	total = int(max(p.discovered.Load(), p.estimate.Load()))
+	return processed, max(total, processed)
 }
 
+// Complete stops rendering, shows the final counts and reports completion.
+// Further calls do nothing.
 func (p *ProgressReporter) Complete() {
-	fmt.Printf("\nCompleted in %v\n", time.Since(p.startTime))
+	p.once.Do(func() {
+		close(p.stop)
+		<-p.done
+		p.out.Update(p.Counts())
+		p.out.Complete(time.Since(p.startTime))
+	})
+}
+
+// render repaints whenever the counts changed since the last repaint.
+func (p *ProgressReporter) render() {
+	defer close(p.done)
+	ticker := time.NewTicker(repaintInterval)
+	defer ticker.Stop()
+
+	lastProcessed, lastTotal := -1, -1
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			processed, total := p.Counts()
+			if processed != lastProcessed || total != lastTotal {
+				p.out.Update(processed, total)
+				lastProcessed, lastTotal = processed, total
+			}
+		}
+	}
 }
@@ -0,0 +1,118 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Reporter renders deletion progress. Calls are serialized by the
+// ProgressReporter that drives it.
+type Reporter interface {
+	Update(processed, total int)
+	Complete(elapsed time.Duration)
+}
+
+// rateAndETA derives throughput and time remaining. Both are zero until
+// there is enough elapsed time and progress to measure.
+func rateAndETA(processed, total int, elapsed time.Duration) (float64, time.Duration) {
+	if elapsed <= 0 || processed <= 0 {
+		return 0, 0
+	}
+	rate := float64(processed) / elapsed.Seconds()
+	eta := time.Duration(float64(total-processed) / rate * float64(time.Second))
+	return rate, eta
+}
+
+// percent renders how much of total is done, followed by a space, or
+// nothing while there is no total yet.
+func percent(processed, total int) string {
+	if total <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.0f%% ", float64(processed)/float64(total)*100)
+}
+
+// TerminalReporter redraws a single progress line using carriage returns.
+type TerminalReporter struct {
+	w     io.Writer
+	start time.Time
+}
+
+func NewTerminalReporter(w io.Writer) *TerminalReporter {
+	return &TerminalReporter{w: w, start: time.Now()}
+}
+
+func (t *TerminalReporter) Update(processed, total int) {
+	rate, eta := rateAndETA(processed, total, time.Since(t.start))
+	fmt.Fprintf(t.w, "\rProgress: %d/%d %s(%.2f/s, ETA: %.1fs)", processed, total, percent(processed, total), rate, eta.Seconds())
+}
+
+func (t *TerminalReporter) Complete(elapsed time.Duration) {
+	fmt.Fprintf(t.w, "\nCompleted in %v\n", elapsed)
+}
+
+// LineReporter prints a complete line of progress at most once per
+// interval, for logs and pipes where carriage-return redraws would pile
+// up into one unreadable line.
+type LineReporter struct {
+	w        io.Writer
+	interval time.Duration
+	start    time.Time
+	last     time.Time
+}
+
+func NewLineReporter(w io.Writer, interval time.Duration) *LineReporter {
+	now := time.Now()
+	return &LineReporter{w: w, interval: interval, start: now, last: now}
+}
+
+func (l *LineReporter) Update(processed, total int) {
+	now := time.Now()
+	if now.Sub(l.last) < l.interval {
+		return
+	}
+	l.last = now
+	rate, eta := rateAndETA(processed, total, now.Sub(l.start))
+	fmt.Fprintf(l.w, "progress: %d/%d %s(%.2f/s, ETA: %.1fs)\n", processed, total, percent(processed, total), rate, eta.Seconds())
+}
+
+func (l *LineReporter) Complete(elapsed time.Duration) {
+	fmt.Fprintf(l.w, "completed in %v\n", elapsed)
+}
+
+// NoopReporter discards all progress.
+type NoopReporter struct{}
+
+func (NoopReporter) Update(int, int)        {}
+func (NoopReporter) Complete(time.Duration) {}
+
+// JSONReporter writes one JSON object per line for every update and a
+// final object on completion.
+type JSONReporter struct {
+	enc   *json.Encoder
+	start time.Time
+}
+
+func NewJSONReporter(w io.Writer) *JSONReporter {
+	return &JSONReporter{enc: json.NewEncoder(w), start: time.Now()}
+}
+
+type jsonProgress struct {
+	Processed int     `json:"processed"`
+	Total     int     `json:"total"`
+	Rate      float64 `json:"rate"`
+	ETA       float64 `json:"eta"`
+	Done      bool    `json:"done,omitempty"`
+	Elapsed   float64 `json:"elapsed,omitempty"`
+}
+
+func (j *JSONReporter) Update(processed, total int) {
+	rate, eta := rateAndETA(processed, total, time.Since(j.start))
+	j.enc.Encode(jsonProgress{Processed: processed, Total: total, Rate: rate, ETA: eta.Seconds()})
+}
+
+func (j *JSONReporter) Complete(elapsed time.Duration) {
+	j.enc.Encode(jsonProgress{Done: true, Elapsed: elapsed.Seconds()})
+}
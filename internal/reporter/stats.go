@@ -2,14 +2,99 @@ package reporter
 
 import (
 	"encoding/json"
+	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yourusername/rmrf/internal/trash"
 )
 
+// Stats collects the results of a run. The counters are updated
+// atomically without taking mu, so workers never contend on a lock for
+// them; while a run is in progress read them through Snapshot, and
+// directly once it has returned.
 type Stats struct {
-	FilesDeleted int      `json:"filesDeleted"`
-	DirsDeleted  int      `json:"dirsDeleted"`
-	Errors       []error  `json:"-"`
-	mu           sync.Mutex
+	FilesDeleted    int64         `json:"filesDeleted"`
+	DirsDeleted     int64         `json:"dirsDeleted"`
+	EntriesSeen     int64         `json:"entriesSeen"`
+	BytesFreed      int64         `json:"bytesFreed"`      // disk blocks of deleted files
+	ApparentBytes   int64         `json:"apparentBytes"`   // sum of their sizes
+	SymlinksRemoved int64         `json:"symlinksRemoved"` // not counted in FilesDeleted
+	SymlinksSkipped int64         `json:"symlinksSkipped"`
+	Kept            int64         `json:"kept"`
+	OtherDevices    int64         `json:"otherDevicesSkipped,omitempty"`
+	AttrProtected   int64         `json:"attrProtected,omitempty"`     // left by chattr +i or +a
+	Subvolumes      int64         `json:"subvolumesSkipped,omitempty"` // nested btrfs subvolumes kept
+	Skipped         []string      `json:"skipped,omitempty"`
+	Drift           []string      `json:"drift,omitempty"`   // kept, not in the plan checked against
+	Pending         []string      `json:"pending,omitempty"` // to be deleted at reboot
+	Warnings        []string      `json:"warnings,omitempty"`
+	Filesystem      string        `json:"filesystem,omitempty"`
+	Profile         string        `json:"profile,omitempty"` // local or network, see config.Profile
+	Threads         int           `json:"threads"`
+	ThreadsSettled  int           `json:"threadsSettled,omitempty"` // active at the end, with adaptive threads
+	LoadPaused      time.Duration `json:"loadPaused,omitempty"`     // waiting for the system load to drop
+	FreeBefore      int64         `json:"freeBefore,omitempty"`
+	FreeAfter       int64         `json:"freeAfter,omitempty"`
+	Duration        time.Duration `json:"duration"`
+	ManifestHash    string        `json:"manifestHash,omitempty"`
+	Roots           []*RootStats  `json:"roots,omitempty"`
+	Trashed         []trash.Item  `json:"trashed,omitempty"`
+	OperationID     string        `json:"operationId,omitempty"`
+	Snapshots       []string      `json:"snapshots,omitempty"` // taken before deleting
+	Passes          []PassStats   `json:"passes,omitempty"`    // with more than one, see config.WithUntilEmpty
+	Errors          []error       `json:"-"`
+	errorCount      int64
+	mu              sync.Mutex // guards the slices and strings
+
+	// OnError, if set, observes each error as AddError records it. It is
+	// called with mu held.
+	OnError func(error) `json:"-"`
+}
+
+// Snapshot is a copy of the counters of a Stats, see Stats.Snapshot.
+type Snapshot struct {
+	FilesDeleted    int64
+	DirsDeleted     int64
+	EntriesSeen     int64
+	BytesFreed      int64
+	ApparentBytes   int64
+	SymlinksRemoved int64
+	SymlinksSkipped int64
+	Kept            int64
+	OtherDevices    int64
+	AttrProtected   int64
+	Subvolumes      int64
+	Errors          int64
+}
+
+// PassStats holds what one pass over the targets deleted.
+type PassStats struct {
+	Files  int64 `json:"files"`
+	Dirs   int64 `json:"dirs"`
+	Errors int64 `json:"errors"`
+}
+
+// RootStats holds the counters for a single target of a run. The totals
+// in Stats always include them. Like those, they are updated atomically.
+type RootStats struct {
+	Path         string `json:"path"`
+	FilesDeleted int64  `json:"filesDeleted"`
+	DirsDeleted  int64  `json:"dirsDeleted"`
+	Errors       int64  `json:"errors"`
+}
+
+func (r *RootStats) IncFiles() {
+	atomic.AddInt64(&r.FilesDeleted, 1)
+}
+
+func (r *RootStats) IncDirs() {
+	atomic.AddInt64(&r.DirsDeleted, 1)
+}
+
+func (r *RootStats) IncErrors() {
+	atomic.AddInt64(&r.Errors, 1)
 }
 
 func DefaultStats() *Stats {
@@ -22,11 +107,176 @@ func (s *Stats) AddError(err error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.Errors = append(s.Errors, err)
+	atomic.AddInt64(&s.errorCount, 1)
+	if s.OnError != nil {
+		s.OnError(err)
+	}
+}
+
+// Snapshot reads the counters without locking, for monitoring a run from
+// another goroutine. Each counter is read atomically, but they are not
+// read at a single instant, so they may be slightly out of step.
+func (s *Stats) Snapshot() Snapshot {
+	return Snapshot{
+		FilesDeleted:    atomic.LoadInt64(&s.FilesDeleted),
+		DirsDeleted:     atomic.LoadInt64(&s.DirsDeleted),
+		EntriesSeen:     atomic.LoadInt64(&s.EntriesSeen),
+		BytesFreed:      atomic.LoadInt64(&s.BytesFreed),
+		ApparentBytes:   atomic.LoadInt64(&s.ApparentBytes),
+		SymlinksRemoved: atomic.LoadInt64(&s.SymlinksRemoved),
+		SymlinksSkipped: atomic.LoadInt64(&s.SymlinksSkipped),
+		Kept:            atomic.LoadInt64(&s.Kept),
+		OtherDevices:    atomic.LoadInt64(&s.OtherDevices),
+		AttrProtected:   atomic.LoadInt64(&s.AttrProtected),
+		Subvolumes:      atomic.LoadInt64(&s.Subvolumes),
+		Errors:          atomic.LoadInt64(&s.errorCount),
+	}
 }
 
+func (s *Stats) IncFiles() {
+	atomic.AddInt64(&s.FilesDeleted, 1)
+}
+
+func (s *Stats) IncDirs() {
+	atomic.AddInt64(&s.DirsDeleted, 1)
+}
+
+// AddBytes records a deleted file's allocated and apparent size.
+func (s *Stats) AddBytes(allocated, apparent int64) {
+	atomic.AddInt64(&s.BytesFreed, allocated)
+	atomic.AddInt64(&s.ApparentBytes, apparent)
+}
+
+// AddRoot starts a per-target breakdown for path.
+func (s *Stats) AddRoot(path string) *RootStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	root := &RootStats{Path: path}
+	s.Roots = append(s.Roots, root)
+	return root
+}
+
+// AddTrashed records an entry moved to the trash.
+func (s *Stats) AddTrashed(item trash.Item) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Trashed = append(s.Trashed, item)
+}
+
+// AddEntries records n directory entries encountered during traversal.
+func (s *Stats) AddEntries(n int) {
+	atomic.AddInt64(&s.EntriesSeen, int64(n))
+}
+
+func (s *Stats) AddSymlinkRemoved() {
+	atomic.AddInt64(&s.SymlinksRemoved, 1)
+}
+
+func (s *Stats) AddSymlinkSkipped() {
+	atomic.AddInt64(&s.SymlinksSkipped, 1)
+}
+
+// AddKept records an entry deliberately left in place by a filter.
+func (s *Stats) AddKept() {
+	atomic.AddInt64(&s.Kept, 1)
+}
+
+// AddOtherDevice records a directory left alone because it is on another
+// filesystem, with a note saying which.
+func (s *Stats) AddOtherDevice(note string) {
+	atomic.AddInt64(&s.OtherDevices, 1)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Warnings = append(s.Warnings, note)
+}
+
+// AddAttrProtected records an entry that could not be removed because of
+// an immutable or append-only attribute.
+func (s *Stats) AddAttrProtected() {
+	atomic.AddInt64(&s.AttrProtected, 1)
+}
+
+// AddPass records the counts of a pass over the targets.
+func (s *Stats) AddPass(p PassStats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Passes = append(s.Passes, p)
+}
+
+// AddSubvolumeSkipped records a btrfs subvolume left in place because
+// deleting subvolumes was not asked for.
+func (s *Stats) AddSubvolumeSkipped() {
+	atomic.AddInt64(&s.Subvolumes, 1)
+}
+
+// AddPending records an entry scheduled to be deleted at the next reboot.
+func (s *Stats) AddPending(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Pending = append(s.Pending, path)
+}
+
+// AddSkipped records an entry the user chose not to delete.
+func (s *Stats) AddSkipped(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Skipped = append(s.Skipped, path)
+}
+
+// AddDrift records an entry kept because it is not in the plan the run
+// was checked against.
+func (s *Stats) AddDrift(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Drift = append(s.Drift, path)
+}
+
+func (s *Stats) AddWarning(msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Warnings = append(s.Warnings, msg)
+}
+
+// AddSnapshot records a snapshot taken before the run deleted anything.
+func (s *Stats) AddSnapshot(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Snapshots = append(s.Snapshots, name)
+}
+
+// FreeSpaceDelta returns how much the filesystem's free space grew during
+// the run, negative if it shrank, if it was measured at both ends.
+func (s *Stats) FreeSpaceDelta() (int64, bool) {
+	if s.FreeBefore == 0 || s.FreeAfter == 0 {
+		return 0, false
+	}
+	return s.FreeAfter - s.FreeBefore, true
+}
+
+// JSON renders the stats as a single JSON object. Errors are included as
+// their messages, and the duration in seconds alongside nanoseconds.
 func (s *Stats) JSON() string {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	data, _ := json.Marshal(s)
+
+	errs := make([]string, len(s.Errors))
+	for i, err := range s.Errors {
+		errs[i] = err.Error()
+	}
+	data, _ := json.Marshal(struct {
+		*Stats
+		DurationSeconds float64  `json:"durationSeconds"`
+		ErrorCount      int      `json:"errorCount"`
+		Errors          []string `json:"errors"`
+	}{s, s.Duration.Seconds(), len(errs), errs})
 	return string(data)
 }
+
+// Logfmt renders a single-line key=value summary for log scraping. The key
+// names are part of the output contract: add new keys, never rename them.
+func (s *Stats) Logfmt() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fmt.Sprintf("rmrf done files=%d dirs=%d errors=%d skipped=%d kept=%d duration=%.3fs bytes=%d symlinks=%d",
+		s.FilesDeleted, s.DirsDeleted, len(s.Errors), s.SymlinksSkipped+int64(len(s.Skipped)), s.Kept, s.Duration.Seconds(), s.BytesFreed, s.SymlinksRemoved)
+}
@@ -0,0 +1,156 @@
+//go:build linux || darwin
+
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// writeInfo writes a .trashinfo record of original and the deletion time
+// to info, then closes it.
+func writeInfo(info *os.File, original string, deleted time.Time) error {
+	escaped := (&url.URL{Path: original}).EscapedPath()
+	_, err := fmt.Fprintf(info, "[Trash Info]\nPath=%s\nDeletionDate=%s\n", escaped, deleted.Format("2006-01-02T15:04:05"))
+	if cerr := info.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// reserveName claims a unique name in the trash by exclusively creating
+// its .trashinfo file, appending ".2", ".3", ... on collisions, including
+// with entries in filesDir that have no .trashinfo file.
+func reserveName(infoDir, filesDir, base string) (string, *os.File, error) {
+	for i := 1; ; i++ {
+		name := base
+		if i > 1 {
+			name = base + "." + strconv.Itoa(i)
+		}
+		if _, err := os.Lstat(filepath.Join(filesDir, name)); err == nil {
+			continue
+		}
+		f, err := os.OpenFile(filepath.Join(infoDir, name+".trashinfo"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			return name, f, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return "", nil, err
+		}
+	}
+}
+
+// topDir finds the mount point of the filesystem holding path by walking
+// up until the device number changes.
+func topDir(path string) (string, error) {
+	var st syscall.Stat_t
+	if err := syscall.Lstat(path, &st); err != nil {
+		return "", err
+	}
+	dev := st.Dev
+
+	dir := path
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir, nil
+		}
+		if err := syscall.Lstat(parent, &st); err != nil {
+			return "", err
+		}
+		if st.Dev != dev {
+			return dir, nil
+		}
+		dir = parent
+	}
+}
+
+// existingAncestor returns abs or its closest ancestor that exists, as
+// the place to judge the volume of abs by once it has been trashed.
+func existingAncestor(abs string) (string, error) {
+	existing := abs
+	var st syscall.Stat_t
+	for {
+		err := syscall.Lstat(existing, &st)
+		if err == nil {
+			return existing, nil
+		}
+		parent := filepath.Dir(existing)
+		if !errors.Is(err, syscall.ENOENT) || parent == existing {
+			return "", &os.PathError{Op: "lstat", Path: existing, Err: err}
+		}
+		existing = parent
+	}
+}
+
+// findIn returns the most recently trashed item recorded in infoDir whose
+// original location was abs. Relative locations, as recorded in the trash
+// of a volume, are taken relative to top.
+func findIn(infoDir, filesDir, top, abs string) (Item, error) {
+	entries, err := os.ReadDir(infoDir)
+	if err != nil {
+		return Item{}, err
+	}
+
+	var found Item
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".trashinfo")
+		if !ok {
+			continue
+		}
+		infoFile := filepath.Join(infoDir, entry.Name())
+		original, deleted, err := readInfo(infoFile)
+		if err != nil {
+			continue
+		}
+		if !filepath.IsAbs(original) {
+			original = filepath.Join(top, original)
+		}
+		if original != abs {
+			continue
+		}
+		if found.TrashPath == "" || deleted.After(found.Deleted) {
+			found = Item{Original: original, TrashPath: filepath.Join(filesDir, name), InfoPath: infoFile, Deleted: deleted}
+		}
+	}
+	if found.TrashPath == "" {
+		return Item{}, fmt.Errorf("%s: %w", abs, os.ErrNotExist)
+	}
+	return found, nil
+}
+
+// readInfo parses the Path and DeletionDate keys of a .trashinfo file.
+func readInfo(path string) (string, time.Time, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	var original string
+	var deleted time.Time
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Path":
+			if original, err = url.PathUnescape(value); err != nil {
+				return "", time.Time{}, err
+			}
+		case "DeletionDate":
+			deleted, _ = time.ParseInLocation("2006-01-02T15:04:05", value, time.Local)
+		}
+	}
+	if original == "" {
+		return "", time.Time{}, fmt.Errorf("%s: missing Path", path)
+	}
+	return original, deleted, nil
+}
@@ -0,0 +1,117 @@
+package trash
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Log is an append-only record of the items one operation moved to the
+// trash, one JSON object per line. Each entry is synced to disk before
+// Append returns, so an interrupted run still leaves a usable log.
+type Log struct {
+	ID   string
+	Path string
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+// LogDir is where operation logs are kept: $XDG_DATA_HOME/rmrf/operations,
+// defaulting to ~/.local/share/rmrf/operations.
+func LogDir() (string, error) {
+	if data := os.Getenv("XDG_DATA_HOME"); data != "" {
+		return filepath.Join(data, "rmrf", "operations"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "rmrf", "operations"), nil
+}
+
+// NewLog creates the log for a new operation. The ID combines the start
+// time and process ID so concurrent runs never collide.
+func NewLog() (*Log, error) {
+	dir, err := LogDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	id := fmt.Sprintf("%s-%d", time.Now().Format("20060102T150405"), os.Getpid())
+	path := filepath.Join(dir, id+".jsonl")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &Log{ID: id, Path: path, f: f}, nil
+}
+
+// Append durably records item.
+func (l *Log) Append(item Item) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return l.f.Sync()
+}
+
+func (l *Log) Close() error {
+	return l.f.Close()
+}
+
+// OpenLogPath resolves an operation ID or a log file path to the log file.
+func OpenLogPath(idOrPath string) (string, bool) {
+	if strings.HasSuffix(idOrPath, ".jsonl") {
+		if _, err := os.Stat(idOrPath); err == nil {
+			return idOrPath, true
+		}
+	}
+	dir, err := LogDir()
+	if err != nil {
+		return "", false
+	}
+	path := filepath.Join(dir, idOrPath+".jsonl")
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// ReadLog returns the items recorded in the log at path.
+func ReadLog(path string) ([]Item, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var items []Item
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var item Item
+		if err := json.Unmarshal(line, &item); err != nil {
+			return items, fmt.Errorf("%s: %w", path, err)
+		}
+		items = append(items, item)
+	}
+	return items, scanner.Err()
+}
@@ -0,0 +1,185 @@
+//go:build windows && (amd64 || arm64)
+
+package trash
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	shell32               = syscall.NewLazyDLL("shell32.dll")
+	procSHFileOperationW  = shell32.NewProc("SHFileOperationW")
+	procSHQueryRecycleBin = shell32.NewProc("SHQueryRecycleBinW")
+)
+
+const (
+	foDelete = 3
+
+	fofSilent         = 0x0004
+	fofNoConfirmation = 0x0010
+	fofAllowUndo      = 0x0040
+	fofNoErrorUI      = 0x0400
+)
+
+// shFileOpStruct is SHFILEOPSTRUCTW. shellapi.h packs it to one byte on
+// 32-bit Windows only, which is why this file is limited to 64-bit.
+type shFileOpStruct struct {
+	hwnd                 uintptr
+	funcCode             uint32
+	from                 *uint16
+	to                   *uint16
+	flags                uint16
+	anyOperationsAborted int32
+	nameMappings         uintptr
+	progressTitle        *uint16
+}
+
+// shQueryRBInfo is SHQUERYRBINFO.
+type shQueryRBInfo struct {
+	size     uint32
+	bytes    int64
+	numItems int64
+}
+
+// Move sends path to the Recycle Bin through the shell, as Explorer's
+// Delete does, so it can be restored from there as well as with Restore.
+// Volumes without a Recycle Bin, like network shares, give ErrUnavailable.
+func Move(path string) (Item, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Item{}, err
+	}
+
+	volume, err := syscall.UTF16PtrFromString(filepath.VolumeName(abs) + `\`)
+	if err != nil {
+		return Item{}, err
+	}
+	info := shQueryRBInfo{size: uint32(unsafe.Sizeof(shQueryRBInfo{}))}
+	if hr, _, _ := procSHQueryRecycleBin.Call(uintptr(unsafe.Pointer(volume)), uintptr(unsafe.Pointer(&info))); hr != 0 {
+		return Item{}, fmt.Errorf("%w: no Recycle Bin on %s", ErrUnavailable, filepath.VolumeName(abs))
+	}
+
+	// pFrom is a list of names, each NUL-terminated, ending in another NUL.
+	from, err := syscall.UTF16FromString(abs)
+	if err != nil {
+		return Item{}, err
+	}
+	from = append(from, 0)
+	op := shFileOpStruct{
+		funcCode: foDelete,
+		from:     &from[0],
+		flags:    fofAllowUndo | fofNoConfirmation | fofSilent | fofNoErrorUI,
+	}
+	if code, _, _ := procSHFileOperationW.Call(uintptr(unsafe.Pointer(&op))); code != 0 {
+		return Item{}, fmt.Errorf("recycling %s: SHFileOperation error %#x", abs, code)
+	}
+	if op.anyOperationsAborted != 0 {
+		return Item{}, fmt.Errorf("recycling %s: aborted", abs)
+	}
+
+	item, err := Find(abs)
+	if err != nil {
+		// It is in the Recycle Bin, only Restore can't find it there.
+		return Item{Original: abs, Deleted: time.Now()}, nil
+	}
+	return item, nil
+}
+
+// Find returns the most recently recycled item whose original location
+// was path, from the current user's Recycle Bin on path's volume.
+func Find(path string) (Item, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Item{}, err
+	}
+	bin, err := recycleBin(abs)
+	if err != nil {
+		return Item{}, err
+	}
+	entries, err := os.ReadDir(bin)
+	if err != nil {
+		return Item{}, err
+	}
+
+	var found Item
+	for _, entry := range entries {
+		suffix, ok := strings.CutPrefix(entry.Name(), "$I")
+		if !ok {
+			continue
+		}
+		infoFile := filepath.Join(bin, entry.Name())
+		original, deleted, err := readRecycleInfo(infoFile)
+		if err != nil || !strings.EqualFold(original, abs) {
+			continue
+		}
+		if found.TrashPath == "" || deleted.After(found.Deleted) {
+			found = Item{Original: original, TrashPath: filepath.Join(bin, "$R"+suffix), InfoPath: infoFile, Deleted: deleted}
+		}
+	}
+	if found.TrashPath == "" {
+		return Item{}, fmt.Errorf("%s: %w", abs, os.ErrNotExist)
+	}
+	return found, nil
+}
+
+// recycleBin returns the current user's Recycle Bin folder on the volume
+// of path, \$Recycle.Bin\<SID>.
+func recycleBin(path string) (string, error) {
+	token, err := syscall.OpenCurrentProcessToken()
+	if err != nil {
+		return "", err
+	}
+	defer token.Close()
+	user, err := token.GetTokenUser()
+	if err != nil {
+		return "", err
+	}
+	sid, err := user.User.Sid.String()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.VolumeName(path)+`\`, "$Recycle.Bin", sid), nil
+}
+
+// readRecycleInfo parses a $I file, which records a recycled item's
+// original path and deletion time. Version 1 (Vista to 8.1) has a fixed
+// MAX_PATH name field, version 2 (Windows 10 on) a length-prefixed one.
+func readRecycleInfo(path string) (string, time.Time, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if len(data) < 24 {
+		return "", time.Time{}, fmt.Errorf("%s: truncated", path)
+	}
+
+	raw := binary.LittleEndian.Uint64(data[16:24])
+	ft := syscall.Filetime{LowDateTime: uint32(raw), HighDateTime: uint32(raw >> 32)}
+	deleted := time.Unix(0, ft.Nanoseconds())
+
+	var name []byte
+	switch binary.LittleEndian.Uint64(data[0:8]) {
+	case 1:
+		name = data[24:]
+	case 2:
+		if len(data) < 28 {
+			return "", time.Time{}, fmt.Errorf("%s: truncated", path)
+		}
+		name = data[28:]
+	default:
+		return "", time.Time{}, fmt.Errorf("%s: unknown version", path)
+	}
+
+	chars := make([]uint16, len(name)/2)
+	for i := range chars {
+		chars[i] = binary.LittleEndian.Uint16(name[2*i:])
+	}
+	return syscall.UTF16ToString(chars), deleted, nil
+}
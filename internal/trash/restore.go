@@ -0,0 +1,68 @@
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// ErrConflict is returned when the original location of an item is
+// occupied and the conflict policy is ConflictFail.
+var ErrConflict = errors.New("original path already exists")
+
+// Conflict selects what Restore does when the original path is occupied.
+type Conflict int
+
+const (
+	ConflictFail      Conflict = iota // leave the item in the trash
+	ConflictOverwrite                 // remove what is there, then restore
+	ConflictRename                    // restore next to it as "<name>.restored"
+)
+
+// Restore moves item back to its original location, recreating missing
+// parent directories, and removes its .trashinfo file. It returns the path
+// the item was restored to.
+func Restore(item Item, policy Conflict) (string, error) {
+	if _, err := os.Lstat(item.TrashPath); err != nil {
+		return "", fmt.Errorf("%s is no longer in the trash: %w", item.TrashPath, err)
+	}
+
+	dest := item.Original
+	if _, err := os.Lstat(dest); err == nil {
+		switch policy {
+		case ConflictOverwrite:
+			if err := os.RemoveAll(dest); err != nil {
+				return "", err
+			}
+		case ConflictRename:
+			dest = freeName(dest + ".restored")
+		default:
+			return "", fmt.Errorf("%w: %s", ErrConflict, dest)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(item.TrashPath, dest); err != nil {
+		return "", err
+	}
+	if item.InfoPath != "" {
+		os.Remove(item.InfoPath)
+	}
+	return dest, nil
+}
+
+// freeName returns path, or path with ".2", ".3", ... appended, whichever
+// does not exist yet.
+func freeName(path string) string {
+	candidate := path
+	for i := 2; ; i++ {
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+		candidate = path + "." + strconv.Itoa(i)
+	}
+}
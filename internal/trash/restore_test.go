@@ -0,0 +1,95 @@
+package trash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// trashedItem makes an item as Move would leave it, holding "trashed",
+// with its original location below a temp directory occupied by the given
+// files, and returns it.
+func trashedItem(t *testing.T, occupied ...string) Item {
+	t.Helper()
+	dir := t.TempDir()
+	item := Item{
+		Original:  filepath.Join(dir, "home", "file"),
+		TrashPath: filepath.Join(dir, "trash", "files", "file"),
+		InfoPath:  filepath.Join(dir, "trash", "info", "file.trashinfo"),
+	}
+	for _, path := range []string{item.TrashPath, item.InfoPath} {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("trashed"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range occupied {
+		path := filepath.Join(dir, "home", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("current"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return item
+}
+
+func TestRestoreConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		occupied []string
+		policy   Conflict
+		want     string // the name restored to, empty if not restored
+		err      error
+	}{
+		{"free", nil, ConflictFail, "file", nil},
+		{"fail", []string{"file"}, ConflictFail, "", ErrConflict},
+		{"overwrite", []string{"file"}, ConflictOverwrite, "file", nil},
+		{"rename", []string{"file"}, ConflictRename, "file.restored", nil},
+		{"rename again", []string{"file", "file.restored"}, ConflictRename, "file.restored.2", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := trashedItem(t, tt.occupied...)
+			home := filepath.Dir(item.Original)
+
+			dest, err := Restore(item, tt.policy)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Restore = %v, want %v", err, tt.err)
+			}
+			for _, name := range tt.occupied {
+				if name == tt.want {
+					continue // overwritten
+				}
+				if data, err := os.ReadFile(filepath.Join(home, name)); err != nil || string(data) != "current" {
+					t.Errorf("%s holds %q, %v; want it left alone", name, data, err)
+				}
+			}
+			if tt.want == "" {
+				if _, err := os.Lstat(item.TrashPath); err != nil {
+					t.Errorf("item left the trash: %v", err)
+				}
+				if _, err := os.Lstat(item.InfoPath); err != nil {
+					t.Errorf("trashinfo removed: %v", err)
+				}
+				return
+			}
+
+			if want := filepath.Join(home, tt.want); dest != want {
+				t.Errorf("restored to %s, want %s", dest, want)
+			}
+			if data, err := os.ReadFile(dest); err != nil || string(data) != "trashed" {
+				t.Errorf("%s holds %q, %v; want the trashed item", dest, data, err)
+			}
+			for _, path := range []string{item.TrashPath, item.InfoPath} {
+				if _, err := os.Lstat(path); !os.IsNotExist(err) {
+					t.Errorf("%s still exists: %v", path, err)
+				}
+			}
+		})
+	}
+}
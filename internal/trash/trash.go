@@ -0,0 +1,25 @@
+// Package trash moves files into the platform's trash can instead of
+// deleting them, so they can be restored later.
+package trash
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrUnsupported is returned on platforms without a trash
+	// implementation.
+	ErrUnsupported = errors.New("trash is not supported on this platform")
+	// ErrUnavailable is returned when there is a trash implementation but
+	// no trash for the given path, like a network share on Windows.
+	ErrUnavailable = errors.New("trash is not available")
+)
+
+// Item describes one entry that was moved to the trash.
+type Item struct {
+	Original  string    `json:"original"`  // absolute path before trashing
+	TrashPath string    `json:"trashPath"` // where the entry now lives
+	InfoPath  string    `json:"infoPath,omitempty"`
+	Deleted   time.Time `json:"deleted"`
+}
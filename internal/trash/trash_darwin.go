@@ -0,0 +1,119 @@
+package trash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// infoDirName is where, inside a trash, the .trashinfo records of what
+// rmrf put there are kept. Finder hides it, and emptying the trash takes
+// it along with the items.
+const infoDirName = ".rmrf-info"
+
+// Move moves path into the trash where Finder would: ~/.Trash when path is
+// on the same volume as the home directory, otherwise the volume's
+// .Trashes/$uid. Finder's Put Back reads records of its own that have no
+// public format, so the original location is recorded in a .trashinfo
+// file, as on Linux, which Find and Restore use.
+func Move(path string) (Item, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Item{}, err
+	}
+	dir, err := trashDir(abs)
+	if err != nil {
+		return Item{}, err
+	}
+	if err := makeTrash(dir); err != nil {
+		return Item{}, err
+	}
+
+	infoDir := filepath.Join(dir, infoDirName)
+	if err := os.MkdirAll(infoDir, 0700); err != nil {
+		return Item{}, err
+	}
+
+	now := time.Now()
+	name, info, err := reserveName(infoDir, dir, filepath.Base(abs))
+	if err != nil {
+		return Item{}, err
+	}
+	err = writeInfo(info, abs, now)
+	infoFile := filepath.Join(infoDir, name+".trashinfo")
+	if err != nil {
+		os.Remove(infoFile)
+		return Item{}, err
+	}
+
+	dest := filepath.Join(dir, name)
+	if err := os.Rename(abs, dest); err != nil {
+		os.Remove(infoFile)
+		return Item{}, err
+	}
+	return Item{Original: abs, TrashPath: dest, InfoPath: infoFile, Deleted: now}, nil
+}
+
+// Find returns the most recently trashed item whose original location was
+// path, from the trash Move would have used for it.
+func Find(path string) (Item, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Item{}, err
+	}
+	dir, err := trashDir(abs)
+	if err != nil {
+		return Item{}, err
+	}
+	return findIn(filepath.Join(dir, infoDirName), dir, "", abs)
+}
+
+// trashDir returns the trash for abs, going by the volume of its closest
+// existing ancestor when abs itself is gone, as it is once trashed.
+func trashDir(abs string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	var homeSt syscall.Stat_t
+	if err := syscall.Stat(home, &homeSt); err != nil {
+		return "", err
+	}
+
+	existing, err := existingAncestor(abs)
+	if err != nil {
+		return "", err
+	}
+	var st syscall.Stat_t
+	if err := syscall.Lstat(existing, &st); err != nil {
+		return "", err
+	}
+	if st.Dev == homeSt.Dev {
+		return filepath.Join(home, ".Trash"), nil
+	}
+
+	top, err := topDir(existing)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(top, ".Trashes", strconv.Itoa(os.Getuid())), nil
+}
+
+// makeTrash creates the trash dir if need be. A volume's .Trashes is
+// shared by its users, so like Finder it is made writable by all but
+// listable by none, and sticky.
+func makeTrash(dir string) error {
+	if shared := filepath.Dir(dir); filepath.Base(shared) == ".Trashes" {
+		if err := os.Mkdir(shared, 0333); err == nil {
+			if err := os.Chmod(shared, 0333|os.ModeSticky); err != nil {
+				return err
+			}
+		} else if !errors.Is(err, os.ErrExist) {
+			return err
+		}
+	}
+	return os.MkdirAll(dir, 0700)
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !(windows && (amd64 || arm64))
+
+package trash
+
+// Move is not implemented on this platform.
+func Move(path string) (Item, error) {
+	return Item{}, ErrUnsupported
+}
+
+// Find is not implemented on this platform.
+func Find(path string) (Item, error) {
+	return Item{}, ErrUnsupported
+}
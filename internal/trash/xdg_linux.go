@@ -0,0 +1,135 @@
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Move moves path into the trash following the freedesktop.org Trash
+// specification: the home trash when path is on the same filesystem as
+// it, otherwise a .Trash-$uid directory at the top of path's filesystem.
+// A .trashinfo file recording the original location is written first so
+// desktop environments can restore the entry.
+func Move(path string) (Item, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Item{}, err
+	}
+
+	home, err := homeTrash()
+	if err != nil {
+		return Item{}, err
+	}
+
+	item, err := moveInto(home, abs, abs)
+	if !errors.Is(err, syscall.EXDEV) {
+		return item, err
+	}
+
+	top, err := topDir(abs)
+	if err != nil {
+		return Item{}, err
+	}
+	rel, err := filepath.Rel(top, abs)
+	if err != nil {
+		return Item{}, err
+	}
+	return moveInto(filepath.Join(top, ".Trash-"+strconv.Itoa(os.Getuid())), abs, rel)
+}
+
+// homeTrash returns $XDG_DATA_HOME/Trash, defaulting to
+// ~/.local/share/Trash.
+func homeTrash() (string, error) {
+	if data := os.Getenv("XDG_DATA_HOME"); data != "" {
+		return filepath.Join(data, "Trash"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "Trash"), nil
+}
+
+// moveInto trashes abs into the trash directory dir, recording infoPath
+// (absolute for the home trash, relative to the volume top otherwise).
+func moveInto(dir, abs, infoPath string) (Item, error) {
+	filesDir, infoDir := filepath.Join(dir, "files"), filepath.Join(dir, "info")
+	for _, d := range []string{filesDir, infoDir} {
+		if err := os.MkdirAll(d, 0700); err != nil {
+			return Item{}, err
+		}
+	}
+
+	now := time.Now()
+	name, info, err := reserveName(infoDir, filesDir, filepath.Base(abs))
+	if err != nil {
+		return Item{}, err
+	}
+
+	err = writeInfo(info, infoPath, now)
+	infoFile := filepath.Join(infoDir, name+".trashinfo")
+	if err != nil {
+		os.Remove(infoFile)
+		return Item{}, err
+	}
+
+	dest := filepath.Join(filesDir, name)
+	if err := os.Rename(abs, dest); err != nil {
+		os.Remove(infoFile)
+		return Item{}, err
+	}
+
+	return Item{Original: abs, TrashPath: dest, InfoPath: infoFile, Deleted: now}, nil
+}
+
+// Find returns the most recently trashed item whose original location was
+// path, from the home trash or the .Trash-$uid directory at the top of the
+// filesystem of path, the two places Move may have put it.
+func Find(path string) (Item, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Item{}, err
+	}
+	home, err := homeTrash()
+	if err != nil {
+		return Item{}, err
+	}
+
+	// Once trashed, abs is gone, so its filesystem is that of the closest
+	// ancestor left.
+	existing, err := existingAncestor(abs)
+	if err != nil {
+		return Item{}, err
+	}
+	top, err := topDir(existing)
+	if err != nil {
+		return Item{}, err
+	}
+	dirs := []struct{ path, top string }{
+		{home, ""},
+		{filepath.Join(top, ".Trash-"+strconv.Itoa(os.Getuid())), top},
+	}
+
+	var found Item
+	for _, dir := range dirs {
+		item, err := findIn(filepath.Join(dir.path, "info"), filepath.Join(dir.path, "files"), dir.top, abs)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return Item{}, err
+		}
+		if found.TrashPath == "" || item.Deleted.After(found.Deleted) {
+			found = item
+		}
+	}
+	if found.TrashPath == "" {
+		return Item{}, fmt.Errorf("%s: %w", abs, os.ErrNotExist)
+	}
+	return found, nil
+}
@@ -0,0 +1,64 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFindHomeTrash(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	path := filepath.Join(dir, "file")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	moved, err := Move(path)
+	if err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	found, err := Find(path)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if found.TrashPath != moved.TrashPath || found.Original != path {
+		t.Errorf("Find = %+v, want %+v", found, moved)
+	}
+}
+
+// TestFindVolumeTrash checks that the relative locations recorded in the
+// trash at the top of a filesystem are found, going by that top.
+func TestFindVolumeTrash(t *testing.T) {
+	top := t.TempDir()
+	trashDir := filepath.Join(top, ".Trash-1000")
+	for _, d := range []string{"files", "info"} {
+		if err := os.MkdirAll(filepath.Join(trashDir, d), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Two items trashed from the same place; the later one is wanted.
+	for i, name := range []string{"file", "file.2"} {
+		info, err := os.Create(filepath.Join(trashDir, "info", name+".trashinfo"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		deleted := time.Date(2026, 1, 1+i, 0, 0, 0, 0, time.Local)
+		if err := writeInfo(info, "data/file", deleted); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	abs := filepath.Join(top, "data", "file")
+	item, err := findIn(filepath.Join(trashDir, "info"), filepath.Join(trashDir, "files"), top, abs)
+	if err != nil {
+		t.Fatalf("findIn: %v", err)
+	}
+	if want := filepath.Join(trashDir, "files", "file.2"); item.TrashPath != want || item.Original != abs {
+		t.Errorf("findIn = %+v, want %s from %s", item, want, abs)
+	}
+}